@@ -0,0 +1,197 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultAuditTolerance = 1e-9 // Share of the total a step may drift by
+	MaxAuditDrifts        = 100  // Drifting steps an Auditor keeps
+)
+
+// Tally is the mass of every substance in a system and the energy it holds.
+// Both phases of a material, such as water and steam, count as one
+// substance, and energy includes the latent heat held by a vapor, so boiling
+// leaves a Tally unchanged.
+type Tally struct {
+	Mass   map[string]float64 // kg by substance ID
+	Energy float64            // J, sensible heat above 0 °C plus latent heat
+}
+
+func (t *Tally) add(m MaterialDef, mass, energy float64) {
+	if t.Mass == nil {
+		t.Mass = make(map[string]float64)
+	}
+	if mass != 0 {
+		t.Mass[substance(m)] += mass
+	}
+	t.Energy += energy
+}
+
+// substance returns the ID shared by every phase of m.
+func substance(m MaterialDef) string {
+	if liquid, _, ok := phasePair(m); ok {
+		return liquid.ID
+	}
+	return m.ID
+}
+
+// internalEnergy returns the energy held by mass of m at temp °C.
+func internalEnergy(m MaterialDef, mass, temp float64) float64 {
+	return mass*m.SpecificHeat*temp + latentEnergy(m, mass)
+}
+
+// latentEnergy returns the heat that went into boiling mass of m, if m is a
+// vapor.
+func latentEnergy(m MaterialDef, mass float64) float64 {
+	if liquid, vapor, ok := phasePair(m); ok && sameMaterial(m, vapor) {
+		return mass * liquid.LatentHeat
+	}
+	return 0
+}
+
+// Audit is the check of a single step.
+type Audit struct {
+	Step          int
+	Before, After Tally
+	Flows         Tally // Everything that entered the system, less what left
+}
+
+// Drift returns what appeared, or vanished when negative, without being
+// accounted for.
+func (a Audit) Drift() Tally {
+	d := Tally{Mass: make(map[string]float64), Energy: a.After.Energy - a.Before.Energy - a.Flows.Energy}
+	for _, t := range []Tally{a.Before, a.After, a.Flows} {
+		for id := range t.Mass {
+			d.Mass[id] = a.After.Mass[id] - a.Before.Mass[id] - a.Flows.Mass[id]
+		}
+	}
+	return d
+}
+
+// drifted reports whether any part of the drift is more than tolerance of
+// the amount it was measured against. A NaN anywhere counts as drift.
+func (a Audit) drifted(tolerance float64) bool {
+	d := a.Drift()
+	for id, m := range d.Mass {
+		if !(math.Abs(m) <= tolerance*math.Max(1, a.Before.Mass[id]+math.Abs(a.Flows.Mass[id]))) {
+			return true
+		}
+	}
+	return !(math.Abs(d.Energy) <= tolerance*math.Max(1, math.Abs(a.Before.Energy)+math.Abs(a.Flows.Energy)))
+}
+
+func (a Audit) String() string {
+	d := a.Drift()
+	ids := make([]string, 0, len(d.Mass))
+	for id := range d.Mass {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	fmt.Fprintf(&b, "step %d drift", a.Step)
+	for _, id := range ids {
+		fmt.Fprintf(&b, " %s %+.3g kg", id, d.Mass[id])
+	}
+	fmt.Fprintf(&b, " energy %+.3g J", d.Energy)
+	return b.String()
+}
+
+// Auditor checks that every step conserves mass and energy once the
+// material and heat crossing the edge of the system are accounted for, such
+// as boiler and reactor heat, fuel burnt, leaks, outfalls and heat lost to
+// the air. Set System.Audit to turn it on.
+type Auditor struct {
+	Tolerance float64 // Share of the total a step may drift by, DefaultAuditTolerance if zero
+	Strict    bool    // Panic on drift instead of only recording it
+
+	Last    Audit   // The most recent step
+	Drifts  []Audit // The first MaxAuditDrifts steps that drifted
+	Drifted int     // Steps that drifted in total
+
+	before Tally
+	flows  Tally
+}
+
+// Err returns the first recorded drift as an error, or nil if every step
+// balanced.
+func (a *Auditor) Err() error {
+	if a == nil || a.Drifted == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d steps not conserved, first at %s", a.Drifted, a.Drifts[0])
+}
+
+func (a *Auditor) begin(s *System) {
+	a.before = s.tally()
+	a.flows = Tally{}
+}
+
+func (a *Auditor) end(s *System) {
+	a.Last = Audit{Step: s.Clock.Steps, Before: a.before, After: s.tally(), Flows: a.flows}
+	tolerance := a.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultAuditTolerance
+	}
+	if !a.Last.drifted(tolerance) {
+		return
+	}
+	a.Drifted++
+	if len(a.Drifts) < MaxAuditDrifts {
+		a.Drifts = append(a.Drifts, a.Last)
+	}
+	Logger.Warn("mass or energy not conserved", "audit", a.Last)
+	if a.Strict {
+		panic(fmt.Sprintf("mass or energy not conserved: %v", a.Last))
+	}
+}
+
+// tally sums what every component of the system holds, including changes
+// still queued.
+func (s *System) tally() Tally {
+	var t Tally
+	seen := make(map[Component]bool)
+	for _, c := range append(s.Network(), s.Machines...) {
+		if c == nil || seen[c] {
+			continue
+		}
+		seen[c] = true
+		st := c.GetStructurals()
+		if st == nil {
+			continue
+		}
+		// Heat queued with incoming mass is already in PendingHeat, so
+		// queued mass only adds its latent heat.
+		contents, amounts := st.portions()
+		for i, m := range contents {
+			t.add(m, amounts[i], internalEnergy(m, amounts[i], st.CurrentHeat))
+			if i < len(st.PendingAmounts) {
+				t.add(m, st.PendingAmounts[i], latentEnergy(m, st.PendingAmounts[i]))
+			}
+		}
+		t.Energy += st.PendingHeat
+	}
+	return t
+}
+
+// account tells the auditor that mass of m and energy joules crossed the
+// edge of the system this step, positive when going in. Heat alone passes
+// a nil m.
+func (s *System) account(m *MaterialDef, mass, energy float64) {
+	if s == nil || s.Audit == nil {
+		return
+	}
+	if m == nil {
+		s.Audit.flows.Energy += energy
+		return
+	}
+	s.Audit.flows.add(*m, mass, energy)
+}
+
+// remove accounts for mass of m taken out of c and carried off with its heat.
+func (s *System) remove(c Component, m MaterialDef, mass float64) {
+	s.account(&m, -mass, -internalEnergy(m, mass, c.GetStructurals().CurrentHeat))
+}
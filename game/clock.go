@@ -0,0 +1,94 @@
+package game
+
+import "math"
+
+const (
+	// TicksPerSecond is the rate System.Tick assumes it is called at. It
+	// matches Ebitengine's default TPS.
+	TicksPerSecond = 60
+	MinSpeed       = 0.5
+	MaxSpeed       = 50.0
+	// MaxStepsPerAdvance bounds how many steps one Advance may run, so a long
+	// stall or a very high speed cannot snowball into an ever longer frame.
+	MaxStepsPerAdvance = 64
+)
+
+// SpeedLevels are the multipliers Clock.Faster and Clock.Slower move between.
+var SpeedLevels = []float64{0.5, 1, 2, 5, 10, 20, 50}
+
+// Clock turns wall time into fixed-size simulation steps.
+// The zero value runs at 1x with a step of TimeStep.
+type Clock struct {
+	Step   float64 // Simulated seconds per step, TimeStep if zero
+	Speed  float64 // Simulated seconds per wall second, 1 if zero
+	Paused bool
+	Time   float64 // Simulated seconds elapsed
+	Steps  int     // Steps taken so far
+
+	accumulator float64
+}
+
+// StepSize returns the simulated seconds covered by one step.
+func (c *Clock) StepSize() float64 {
+	if c.Step <= 0 {
+		return TimeStep
+	}
+	return c.Step
+}
+
+// SpeedFactor returns the current speed multiplier.
+func (c *Clock) SpeedFactor() float64 {
+	if c.Speed <= 0 {
+		return 1
+	}
+	return c.Speed
+}
+
+// SetSpeed sets the speed multiplier, clamped to MinSpeed..MaxSpeed.
+func (c *Clock) SetSpeed(x float64) {
+	c.Speed = math.Max(MinSpeed, math.Min(MaxSpeed, x))
+}
+
+// Faster moves to the next entry of SpeedLevels above the current speed.
+func (c *Clock) Faster() {
+	cur := c.SpeedFactor()
+	for _, lvl := range SpeedLevels {
+		if lvl > cur {
+			c.SetSpeed(lvl)
+			return
+		}
+	}
+}
+
+// Slower moves to the next entry of SpeedLevels below the current speed.
+func (c *Clock) Slower() {
+	cur := c.SpeedFactor()
+	for i := len(SpeedLevels) - 1; i >= 0; i-- {
+		if SpeedLevels[i] < cur {
+			c.SetSpeed(SpeedLevels[i])
+			return
+		}
+	}
+}
+
+// Advance accumulates realDt seconds of wall time, scaled by the speed, and
+// returns how many whole steps are now due. Leftover time carries over to the
+// next call. A paused clock accumulates nothing.
+func (c *Clock) Advance(realDt float64) int {
+	if c.Paused || realDt <= 0 {
+		return 0
+	}
+
+	step := c.StepSize()
+	c.accumulator += realDt * c.SpeedFactor()
+
+	// The small epsilon keeps e.g. 10 x (1/60) from landing just short of 1/6.
+	n := int(math.Floor(c.accumulator/step + 1e-9))
+	if n > MaxStepsPerAdvance {
+		n = MaxStepsPerAdvance
+		c.accumulator = 0
+		return n
+	}
+	c.accumulator = math.Max(0, c.accumulator-float64(n)*step)
+	return n
+}
@@ -12,6 +12,12 @@ type Component interface {
 	GetStructurals() *Structurals
 }
 
+// Updater is implemented by components that act on their own every
+// simulation step, before flows are solved.
+type Updater interface {
+	Update(s *System, dt float64)
+}
+
 // Basics contains basic information for a component..
 type Basics struct {
 	Id         int
@@ -31,10 +37,10 @@ func (b *Basics) GetColor() (byte, byte, byte) {
 
 // Structurals contains structural properties for a component.
 type Structurals struct {
-	MaxHeat     int
-	CurrentHeat int
-	MaxPressure int
-	Pressure    float64
+	MaxHeat     float64 // Highest safe temperature in °C, unlimited if zero
+	CurrentHeat float64 // Temperature in °C
+	MaxPressure float64 // Highest safe absolute pressure in Pa, unlimited if zero
+	Pressure    float64 // Absolute pressure in Pa on the last step
 	Area        float64
 	MaxHeight   float64
 	MaxVolume   float64
@@ -45,6 +51,18 @@ type Structurals struct {
 	PendingChange float64
 	IsJunction    bool
 	Contents      []MaterialDef
+	Amounts       []float64 // Mass of each entry of Contents
+	// PendingAmounts is the per-material part of PendingChange.
+	PendingAmounts []float64
+	// Stratified vessels let their densest contents out first instead of
+	// a well-mixed share, or their lightest first with DrawTop.
+	Stratified bool
+	DrawTop    bool
+	// PendingHeat is thermal energy in joules queued alongside PendingChange.
+	PendingHeat float64
+	Overheated  bool // CurrentHeat passed MaxHeat on the last step
+	Condition   Condition
+	Damage      float64 // 0-1, the component bursts at 1
 }
 
 // Reservoir represents any component to hold MaterialDef.
@@ -57,14 +75,19 @@ func (r *Reservoir) GetStructurals() *Structurals {
 	return &r.Structurals
 }
 
-// Pipe moves MaterialDef between components.
+// Pipe moves MaterialDef between components. Flow follows the head
+// difference in either direction unless CheckValve is set, in which case
+// it only ever runs From -> To.
 type Pipe struct {
 	Basics
 	Structurals
-	From     Component
-	To       Component
-	Length   float64
-	PumpHead float64
+	From       Component `json:"-"`
+	To         Component `json:"-"`
+	Length     float64
+	PumpHead   float64 // m of head a pump adds across the pipe
+	CheckValve bool
+	Flow       float64 // kg/s out through To on the last step, negative when reversed
+	Valve      *Valve  `json:"-"` // Throttles the pipe, fully open if nil
 }
 
 func NewPipe(from, to Component, len, radius float64) *Pipe {
@@ -86,8 +109,292 @@ func (p *Pipe) GetStructurals() *Structurals {
 	return &p.Structurals
 }
 
-// Generator represents any component to generate.
+// endHead returns the pump head added at each end of the pipe. The pipe
+// holds fluid between its ends, so PumpHead is split between them to count
+// only once across the pipe.
+func (p *Pipe) endHead() float64 {
+	return p.PumpHead / 2
+}
+
+// OpenFraction returns the share of the pipe's area open to flow.
+func (p *Pipe) OpenFraction() float64 {
+	if p.Valve == nil {
+		return 1
+	}
+	return math.Max(0, math.Min(1, p.Valve.Opening))
+}
+
+// Turbine is implemented by components that take part of the head across
+// their outflows and turn it into work.
+type Turbine interface {
+	Component
+	// HeadFraction is the share of the head across an outflow the turbine takes.
+	HeadFraction() float64
+	// Extract records work in joules done by volume m^3 of fluid passing
+	// through over dt.
+	Extract(work, volume, dt float64)
+}
+
+// Generator is a turbine in the pipe network. Fluid leaving it gives up part
+// of its head, which is turned into electrical power.
 type Generator struct {
 	Basics
 	Structurals
+	Efficiency float64 // Share of the work turned into electricity, at RatedFlow if set
+	Load       float64 // Share of the head taken, DefaultTurbineLoad if zero
+	// RatedFlow is the flow in m^3/s a water turbine is built for. Away
+	// from it less of the work becomes electricity, see EfficiencyAt.
+	RatedFlow float64
+	Output    float64 // W produced on the last step
+	Discharge float64 // m^3/s passed through on the last step
+}
+
+func (g *Generator) GetStructurals() *Structurals {
+	return &g.Structurals
+}
+
+// Update clears the output before this step's flows are solved.
+func (g *Generator) Update(s *System, dt float64) {
+	g.Output = 0
+	g.Discharge = 0
+}
+
+// HeadFraction returns the share of the head the generator takes.
+func (g *Generator) HeadFraction() float64 {
+	if g.Load <= 0 {
+		return DefaultTurbineLoad
+	}
+	return math.Min(g.Load, 1)
+}
+
+// Extract turns work into electrical output. Each outflow is rated on its
+// own flow.
+func (g *Generator) Extract(work, volume, dt float64) {
+	if dt <= 0 {
+		return
+	}
+	q := volume / dt
+	g.Discharge += q
+	g.Output += g.EfficiencyAt(q) * work / dt
+}
+
+// EfficiencyAt returns the share of work turned into electricity at a flow
+// of q m^3/s. Without a RatedFlow it is always Efficiency. With one it
+// peaks at Efficiency on RatedFlow and falls away on a parabola, to nothing
+// at no flow or twice the rated flow.
+func (g *Generator) EfficiencyAt(q float64) float64 {
+	if g.RatedFlow <= 0 {
+		return g.Efficiency
+	}
+	x := q/g.RatedFlow - 1
+	return math.Max(0, g.Efficiency*(1-x*x))
+}
+
+// PowerOutput returns the power the generator puts on the grid.
+func (g *Generator) PowerOutput() float64 {
+	return g.Output
+}
+
+// Boiler is a vessel that heats its contents. Feed water settles at the
+// bottom and steam leaves from the top.
+type Boiler struct {
+	Basics
+	Structurals
+	HeatInput float64 // W delivered to the contents
+}
+
+func (b *Boiler) GetStructurals() *Structurals {
+	return &b.Structurals
+}
+
+// Update queues the boiler's heat input for this step.
+func (b *Boiler) Update(s *System, dt float64) {
+	b.QueueHeat(b.HeatInput * dt)
+	s.account(nil, 0, b.HeatInput*dt)
+}
+
+// Consumer is an electrical load on the grid, such as a town or a factory.
+type Consumer struct {
+	Basics
+	Structurals
+	Demand   float64 // W wanted from the grid
+	Supplied float64 // W received on the last step
+}
+
+func (c *Consumer) GetStructurals() *Structurals {
+	return &c.Structurals
+}
+
+// PowerDemand returns the power the consumer wants.
+func (c *Consumer) PowerDemand() float64 {
+	return c.Demand
+}
+
+// Supply records the power the grid delivered.
+func (c *Consumer) Supply(w float64) {
+	c.Supplied = w
+}
+
+// Wire is a power line on a single tile. Wires on the same or neighbouring
+// tiles join into one network.
+type Wire struct {
+	Basics
+	Structurals
+	X, Y int
+}
+
+func (w *Wire) GetStructurals() *Structurals {
+	return &w.Structurals
+}
+
+// Pump drives a pipe by setting its PumpHead every step. The head it gives
+// falls off with flow along a parabolic curve, and both scale with speed.
+// A pump with a RatedPower only runs on the power the grid delivers.
+type Pump struct {
+	Basics
+	Structurals
+	Pipe        *Pipe   `json:"-"`
+	ShutoffHead float64 // m of head at zero flow and full speed
+	MaxFlow     float64 // m^3/s at zero head and full speed
+	On          bool
+	Speed       float64 // 0-1 share of full speed
+	RatedPower  float64 // W drawn at full speed, none needed if zero
+	Supplied    float64 // W received on the last step
+}
+
+func (p *Pump) GetStructurals() *Structurals {
+	return &p.Structurals
+}
+
+// SetOn switches the pump on or off.
+func (p *Pump) SetOn(on bool) {
+	p.On = on
+}
+
+// SetSpeed sets the pump speed, clamped to 0-1.
+func (p *Pump) SetSpeed(speed float64) {
+	p.Speed = math.Max(0, math.Min(1, speed))
+}
+
+// PowerDemand returns the power the pump wants, which grows with the cube
+// of its speed.
+func (p *Pump) PowerDemand() float64 {
+	if !p.On {
+		return 0
+	}
+	return p.RatedPower * p.Speed * p.Speed * p.Speed
+}
+
+// Supply records the power the grid delivered.
+func (p *Pump) Supply(w float64) {
+	p.Supplied = w
+}
+
+// EffectiveSpeed returns the speed the pump actually turns at, slowed down
+// when the grid could not meet its demand.
+func (p *Pump) EffectiveSpeed() float64 {
+	if !p.On {
+		return 0
+	}
+	speed := math.Max(0, math.Min(1, p.Speed))
+	if demand := p.PowerDemand(); demand > 0 {
+		speed *= math.Cbrt(math.Min(1, p.Supplied/demand))
+	}
+	return speed
+}
+
+// Head returns the head the pump gives at a volumetric flow q in m^3/s.
+func (p *Pump) Head(q float64) float64 {
+	speed := p.EffectiveSpeed()
+	if speed == 0 || p.MaxFlow <= 0 {
+		return 0
+	}
+	ratio := math.Max(0, q) / (p.MaxFlow * speed)
+	return math.Max(0, p.ShutoffHead*speed*speed*(1-ratio*ratio))
+}
+
+// Update sets the pipe's PumpHead to the pump's operating point for this
+// step: the head at which the flow it drives through the pipe is the flow
+// the pump curve gives for that head. The flow can only fall as the head
+// does, so the point is found by bisection as for a junction's head.
+func (p *Pump) Update(s *System, dt float64) {
+	if p.Pipe == nil {
+		return
+	}
+	lo, hi := 0.0, p.Head(0)
+	for range 60 {
+		mid := (lo + hi) / 2
+		if p.Head(pumpedFlow(p.Pipe, mid, dt)) > mid {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	p.Pipe.PumpHead = (lo + hi) / 2
+}
+
+// Junction joins three or more pipes, as a tee, cross or manifold. It
+// stores nothing and passes on whatever reaches it, see System.Solve.
+type Junction struct {
+	Basics
+	Structurals
+}
+
+func (j *Junction) GetStructurals() *Structurals {
+	return &j.Structurals
+}
+
+// Outfall is where water leaves the plant for good, such as the river below
+// a dam's tailrace. It takes whatever reaches it and carries it away on the
+// next step, so its level stays at BaseElevation.
+type Outfall struct {
+	Basics
+	Structurals
+	Discharged float64 // kg carried away in total
+}
+
+func (o *Outfall) GetStructurals() *Structurals {
+	return &o.Structurals
+}
+
+// Update carries away what arrived on the last step.
+func (o *Outfall) Update(s *System, dt float64) {
+	o.Discharged += o.Quantity
+	for _, m := range append([]MaterialDef(nil), o.Contents...) {
+		s.remove(o, m, o.AmountOf(m))
+		o.AddMaterial(m, -o.AmountOf(m))
+	}
+}
+
+// Valve throttles the pipe it is fitted to. Opening scales the pipe's flow
+// area from 0 (shut) to 1 (fully open).
+type Valve struct {
+	Basics
+	Structurals
+	Opening float64
+}
+
+func (v *Valve) GetStructurals() *Structurals {
+	return &v.Structurals
+}
+
+// SetOpening sets the opening, clamped to 0-1.
+func (v *Valve) SetOpening(f float64) {
+	v.Opening = math.Max(0, math.Min(1, f))
+}
+
+// Toggle shuts an open valve and fully opens a shut one.
+func (v *Valve) Toggle() {
+	if v.Opening > 0 {
+		v.Opening = 0
+	} else {
+		v.Opening = 1
+	}
+}
+
+// Fit installs the valve on p, replacing any valve already there.
+func (v *Valve) Fit(p *Pipe) {
+	if p != nil {
+		p.Valve = v
+	}
 }
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Disposal is what Despawn does with the contents of what it removes.
+type Disposal int
+
+const (
+	SpillContents  Disposal = iota // Onto the tile the component stood on
+	RefundContents                 // Back to the caller
+	VanishContents                 // Nowhere, they are gone
+)
+
+// Despawn removes c, made by Spawn, from l and its System, together with
+// every pipe running to or from it. Anything left referring to what was
+// removed, such as a pump driving a removed pipe or a valve fitted to one,
+// stays in place but unattached. Material that already leaked goes onto the
+// tiles as CollectLeaks would put it. What the removed components still held
+// is dealt with by d, and returned when d is RefundContents or when there is
+// no tile to spill it on.
+func (l *Level) Despawn(c Component, d Disposal) (Structurals, error) {
+	var refund Structurals
+	if c == nil || !slices.ContainsFunc(l.spawns, func(sp spawned) bool { return sp.component == c }) {
+		return refund, fmt.Errorf("%s was not spawned in this level", Identifier(c))
+	}
+	defer l.Revalidate()
+
+	gone := []Component{c}
+	removed := map[Component]bool{c: true}
+	attached := func(p *Pipe) {
+		if p != nil && !removed[p] && (p.From == c || p.To == c) {
+			gone = append(gone, p)
+			removed[p] = true
+		}
+	}
+	for _, sp := range l.spawns {
+		if p, ok := sp.component.(*Pipe); ok {
+			attached(p)
+		}
+	}
+	if l.System != nil {
+		for _, p := range l.System.Pipes {
+			attached(p)
+		}
+	}
+
+	for _, comp := range gone {
+		t := l.tileOf(comp)
+		contents, amounts := held(comp)
+		for i, m := range contents {
+			if amounts[i] <= 0 {
+				continue
+			}
+			switch {
+			case d == SpillContents && t != nil:
+				t.Spill.AddMaterial(m, amounts[i])
+			case d == SpillContents, d == RefundContents:
+				refund.AddMaterial(m, amounts[i])
+			}
+		}
+		if l.System == nil {
+			continue
+		}
+		for _, leak := range l.System.Leaks {
+			if leak.Component == comp && t != nil {
+				t.Spill.AddMaterial(leak.Material, leak.Mass)
+			}
+		}
+	}
+
+	l.removeEntities(func(e *Entity) bool { return removed[e.Component] })
+	l.spawns = slices.DeleteFunc(l.spawns, func(sp spawned) bool { return removed[sp.component] })
+
+	var left []Component
+	for _, sp := range l.spawns {
+		left = append(left, sp.component)
+	}
+	if s := l.System; s != nil {
+		s.Nodes = slices.DeleteFunc(s.Nodes, func(n Component) bool { return removed[n] })
+		s.Pipes = slices.DeleteFunc(s.Pipes, func(p *Pipe) bool { return removed[p] })
+		s.Machines = slices.DeleteFunc(s.Machines, func(m Component) bool { return removed[m] })
+		s.Leaks = slices.DeleteFunc(s.Leaks, func(leak Leak) bool { return removed[leak.Component] })
+		for _, comp := range gone {
+			s.Grid.Detach(comp)
+		}
+		left = append(left, s.Nodes...)
+		left = append(left, s.Machines...)
+		for _, p := range s.Pipes {
+			if p != nil {
+				left = append(left, p)
+			}
+		}
+	}
+	for _, comp := range left {
+		for _, r := range refs(comp) {
+			if removed[r.to] {
+				if err := setRef(comp, r.name, nil); err != nil {
+					return refund, err
+				}
+			}
+		}
+	}
+	return refund, nil
+}
+
+// tileOf returns the tile of the first entity in l drawing c, or nil.
+func (l *Level) tileOf(c Component) *Tile {
+	for _, e := range l.entities {
+		if e.Component == c {
+			return l.Tile(e.X, e.Y)
+		}
+	}
+	return nil
+}
+
+// held returns the materials c holds and the mass of each. What rides a
+// conveyor is already in its Structurals, its Loads only track where.
+func held(c Component) ([]MaterialDef, []float64) {
+	st := c.GetStructurals()
+	if st == nil {
+		return nil, nil
+	}
+	return st.portions()
+}
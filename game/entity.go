@@ -64,6 +64,58 @@ func FillPercentSelector(stateMap map[string]string) SpriteSelector {
 	}
 }
 
+// OutputSelector returns a selector that picks the running sprite while a
+// Generator is producing power and the idle sprite otherwise.
+func OutputSelector(running, idle string) SpriteSelector {
+	return func(e *Entity) *Sprite {
+		if g, ok := e.Component.(*Generator); ok && g.Output > 0 {
+			return SpriteSet[running]
+		}
+		return SpriteSet[idle]
+	}
+}
+
+// OpeningSelector returns a selector that picks the open sprite while a
+// Valve lets anything through and the closed sprite once it is shut.
+func OpeningSelector(open, closed string) SpriteSelector {
+	return func(e *Entity) *Sprite {
+		if v, ok := e.Component.(*Valve); ok && v.Opening <= 0 {
+			return SpriteSet[closed]
+		}
+		return SpriteSet[open]
+	}
+}
+
+// FiringSelector returns a selector that picks the lit sprite while a
+// Furnace is burning fuel and the cold sprite otherwise.
+func FiringSelector(lit, cold string) SpriteSelector {
+	return func(e *Entity) *Sprite {
+		if f, ok := e.Component.(*Furnace); ok && f.Burnt > 0 {
+			return SpriteSet[lit]
+		}
+		return SpriteSet[cold]
+	}
+}
+
+// ConditionSelector wraps next so a leaking or burst component shows its
+// damage sprite instead. Undamaged components are left to next.
+func ConditionSelector(next SpriteSelector, leaking, burst string) SpriteSelector {
+	return func(e *Entity) *Sprite {
+		if s := e.Component.GetStructurals(); s != nil {
+			switch s.Condition {
+			case Leaking:
+				return SpriteSet[leaking]
+			case Burst:
+				return SpriteSet[burst]
+			}
+		}
+		if next == nil {
+			return e.Sprite
+		}
+		return next(e)
+	}
+}
+
 // === Entity Factory Functions ===
 // NewEntity creates a simple entity with a static sprite and optional selector.
 func NewEntity(x, y int, comp Component, selector SpriteSelector, drawOrder int) *Entity {
@@ -107,3 +159,18 @@ func NewFloorEntity(x, y int, comp Component, drawOrder int) *Entity {
 func NewPipeEntity(x, y int, comp Component, spriteKey string, drawOrder int) *Entity {
 	return NewEntity(x, y, comp, StaticSpriteSelector(spriteKey), drawOrder)
 }
+
+// NewGeneratorEntity creates a generator entity that shows when it is running.
+func NewGeneratorEntity(x, y int, comp Component, drawOrder int) *Entity {
+	return NewEntity(x, y, comp, OutputSelector("generator_running", "generator_idle"), drawOrder)
+}
+
+// NewValveEntity creates a valve entity that shows whether it is shut.
+func NewValveEntity(x, y int, comp Component, drawOrder int) *Entity {
+	return NewEntity(x, y, comp, OpeningSelector("valve_open", "valve_closed"), drawOrder)
+}
+
+// NewFurnaceEntity creates a furnace entity that shows when it is burning.
+func NewFurnaceEntity(x, y int, comp Component, drawOrder int) *Entity {
+	return NewEntity(x, y, comp, FiringSelector("furnace_lit", "furnace_cold"), drawOrder)
+}
@@ -1,111 +1,180 @@
 package game
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
 type EntityConfig struct {
-	Type       string // "Reservoir", "Pipe", "Wall", "Generator"
+	Type       string // Name of a registered ComponentKind, see ComponentKinds
 	X, Y       int    // Grid coordinates
 	Identifier string // Display ID (e.g. "A", "B")
+	// From and To name already spawned components, by Identifier, for a Pipe
+	// or Conveyor to run between, a Feeder to move from and to, a Furnace
+	// to heat (To only) or the pipe a Pump drives or a Valve is fitted to
+	// (To only).
+	From, To string
 
 	// Physical Properties
-	MaxVolume  float64
-	InitialQty float64
-	Area       float64
-	Contents   *MaterialDef // "Water", "Steam", etc.
+	MaxVolume   float64
+	InitialQty  float64
+	Area        float64
+	Contents    *MaterialDef // "Water", "Steam", etc.
+	Temperature *float64     // °C, AmbientTemp if nil
+	MaxHeat     float64      // °C, unlimited if zero
+	MaxPressure float64      // Pa, unlimited if zero
+	Elevation   float64      // BaseElevation in m
 
 	// Component Specifics
 	PipeLength float64
 	PipeRadius float64
+	CheckValve bool     // Pipe only allows From -> To flow
+	HeatInput  float64  // Boiler heating power in W
+	Efficiency float64  // Generator share of work turned into power, Furnace share of heat delivered
+	Load       float64  // Generator share of head taken
+	Demand     float64  // Consumer power draw in W
+	PumpHead   float64  // Pump shutoff head in m
+	PumpFlow   float64  // Pump flow at zero head in m^3/s
+	RatedPower float64  // Pump power draw at full speed, Reactor heat at full power, in W
+	Opening    *float64 // Valve opening 0-1, fully open if nil
+	Throughput float64  // Conveyor or Feeder rate in kg/s
+	BeltSpeed  float64  // Conveyor speed in tiles/s
+	Path       [][2]int // Further tiles a Conveyor is laid across, after X, Y
+	BurnRate   float64  // Furnace kg/s of fuel at full firing
+	Rods       *float64 // Reactor rod insertion 0-1, fully inserted if nil
+	RatedFlow  float64  // WaterTurbine flow at best efficiency in m^3/s
 
 	// Visuals
 	Sprite string
 }
 
+// ComponentKind tells Level.Spawn how to make one type of component.
+type ComponentKind struct {
+	// Defaults fills in the fields of c left unset, if not nil. Spawn has
+	// already set Area.
+	Defaults func(c *EntityConfig)
+	// Build makes the component and the entity that draws it. Any further
+	// entities, such as the rest of a conveyor, are added to l directly.
+	Build func(l *Level, c EntityConfig) (Component, *Entity, error)
+	// Register adds the component to s. Components go to System.Nodes if
+	// it is nil.
+	Register func(s *System, comp Component)
+	// Connect attaches the component to the ones EntityConfig.From and To
+	// name, either of which may be nil. Kinds without it cannot be given
+	// From or To.
+	Connect func(comp, from, to Component) error
+}
+
+var componentKinds = map[string]ComponentKind{}
+
+// RegisterComponent makes kind available to Spawn as EntityConfig.Type
+// name. It panics if name is taken or kind has no Build.
+func RegisterComponent(name string, kind ComponentKind) {
+	if kind.Build == nil {
+		panic("game: RegisterComponent " + name + " without Build")
+	}
+	if _, taken := componentKinds[name]; taken {
+		panic("game: RegisterComponent called twice for " + name)
+	}
+	componentKinds[name] = kind
+}
+
+// ComponentKinds returns the names of every registered kind, sorted.
+func ComponentKinds() []string {
+	names := make([]string, 0, len(componentKinds))
+	for name := range componentKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Spawn creates an entity based on config and registers it to the level and system.
 func (l *Level) Spawn(c EntityConfig) (*Entity, error) {
-	var comp Component
-	var ent *Entity
+	kind, ok := componentKinds[c.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown component type %q", c.Type)
+	}
 
 	// Defaults
 	if c.Area == 0 {
 		c.Area = 5.0
 	}
-	if c.MaxVolume == 0 {
-		switch c.Type {
-		case "Reservoir":
-			c.MaxVolume = 1000.0
-		case "Pipe":
-			// Calculated from radius/length if not set, but verified later
-		}
+	if kind.Defaults != nil {
+		kind.Defaults(&c)
 	}
 
-	// Prepare Contents
-	var initialContents []MaterialDef
-	if c.InitialQty > 0 {
-		if c.Contents != nil {
-			initialContents = []MaterialDef{*c.Contents}
-		} else {
-			initialContents = []MaterialDef{Water} // Default to water
+	var from, to Component
+	if c.From != "" || c.To != "" {
+		if kind.Connect == nil {
+			return nil, fmt.Errorf("%s takes no From or To", c.Type)
 		}
-	}
-
-	switch c.Type {
-	case "Reservoir":
-		res := &Reservoir{
-			Basics: Basics{
-				Identifier: c.Identifier,
-				Color:      [3]byte{0, 0, 255}, // Default blue-ish
-			},
-			Structurals: Structurals{
-				MaxVolume: c.MaxVolume,
-				Area:      c.Area,
-				Quantity:  c.InitialQty,
-				Contents:  initialContents,
-			},
+		var errFrom, errTo error
+		if c.From != "" {
+			from, errFrom = l.Lookup(c.From)
 		}
-		comp = res
-		// Create Reservoir Entity (visuals)
-		ent = NewReservoirEntity(c.X, c.Y, res, 1)
-
-	case "Pipe":
-		// Pipe requires special handling if we want to connect it here,
-		// but Spawn might just create the unconnected pipe for now.
-		// Connection usually requires references to other components.
-		// For now, we creating a pipe component.
-		if c.PipeLength == 0 {
-			c.PipeLength = 1.0
+		if c.To != "" {
+			to, errTo = l.Lookup(c.To)
 		}
-		if c.PipeRadius == 0 {
-			c.PipeRadius = 0.5
+		if err := errors.Join(errFrom, errTo); err != nil {
+			return nil, err
 		}
+	}
 
-		p := NewPipe(nil, nil, c.PipeLength, c.PipeRadius)
-		p.Identifier = c.Identifier // Pipe usually doesn't show ID, but for debug
-		p.Quantity = c.InitialQty
-
-		comp = p
-
-		sprite := c.Sprite
-		if sprite == "" {
-			sprite = "pipe_horizontal"
-		} // fallback
-		ent = NewPipeEntity(c.X, c.Y, p, sprite, 1)
+	comp, ent, err := kind.Build(l, c)
+	if err == nil && (from != nil || to != nil) {
+		err = kind.Connect(comp, from, to)
+	}
+	if err != nil {
+		// Take back whatever Build already put in the level, such as the
+		// rest of a conveyor.
+		l.removeEntities(func(e *Entity) bool { return comp != nil && e.Component == comp })
+		return nil, err
 	}
 
-	if ent != nil {
-		l.AddEntity(ent)
+	comp.GetStructurals().BaseElevation = c.Elevation
+	// Show damage over whatever the component would normally draw.
+	ent.Selector = ConditionSelector(ent.Selector, "leaking", "burst")
+	l.AddEntity(ent)
 
-		// Auto-register to System
-		if l.System != nil && comp != nil {
-			if pipe, ok := comp.(*Pipe); ok {
-				l.System.Pipes = append(l.System.Pipes, pipe)
-			} else {
-				l.System.Nodes = append(l.System.Nodes, comp)
-			}
+	// Auto-register to System
+	if l.System != nil {
+		if kind.Register != nil {
+			kind.Register(l.System, comp)
+		} else {
+			l.System.Nodes = append(l.System.Nodes, comp)
 		}
+		l.System.Grid.Attach(comp, c.X, c.Y)
+	}
+	l.spawns = append(l.spawns, spawned{config: c, component: comp})
+	if !l.building {
+		l.Revalidate()
 	}
 
 	return ent, nil
 }
 
+// Lookup returns the component Spawn made in l with identifier id. It is an
+// error if there is none, or more than one.
+func (l *Level) Lookup(id string) (Component, error) {
+	var found []Component
+	for _, sp := range l.spawns {
+		if id != "" && sp.component.GetIdentifier() == id {
+			found = append(found, sp.component)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no component named %q", id)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%d components named %q", len(found), id)
+	}
+}
+
 // AddEntity handles adding to tiles and internal list
 func (l *Level) AddEntity(e *Entity) {
 	if l.Tile(e.X, e.Y) != nil {
@@ -113,3 +182,17 @@ func (l *Level) AddEntity(e *Entity) {
 		l.entities = append(l.entities, e)
 	}
 }
+
+// removeEntities takes every entity drop returns true for off its tile and
+// out of l.
+func (l *Level) removeEntities(drop func(e *Entity) bool) {
+	l.entities = slices.DeleteFunc(l.entities, func(e *Entity) bool {
+		if !drop(e) {
+			return false
+		}
+		if t := l.Tile(e.X, e.Y); t != nil {
+			t.entities = slices.DeleteFunc(t.entities, func(te *Entity) bool { return te == e })
+		}
+		return true
+	})
+}
@@ -0,0 +1,131 @@
+package game
+
+import "math"
+
+// Condition is how badly a component has been hurt by over-pressure.
+type Condition int
+
+const (
+	Intact  Condition = iota
+	Warning           // Close to MaxPressure
+	Leaking           // Damaged, losing part of its contents every step
+	Burst             // Ruptured, losing its contents fast
+)
+
+func (c Condition) String() string {
+	switch c {
+	case Warning:
+		return "warning"
+	case Leaking:
+		return "leaking"
+	case Burst:
+		return "burst"
+	}
+	return "intact"
+}
+
+const (
+	WarnRatio   = 0.9  // Share of MaxPressure that raises a Warning
+	DamageRate  = 1.0  // Damage per second for each 100% over MaxPressure
+	LeakDamage  = 0.3  // Damage at which a component starts Leaking
+	LeakRate    = 0.05 // Share of contents lost per second while Leaking
+	BurstRate   = 0.5  // Share of contents lost per second once Burst
+	minLeakMass = 1e-9
+)
+
+// Leak is material that escaped from a damaged component and has not yet
+// been put down on its tile.
+type Leak struct {
+	Component Component
+	Material  MaterialDef
+	Mass      float64
+}
+
+// Repair clears the damage of s and returns it to Intact.
+func (s *Structurals) Repair() {
+	s.Damage = 0
+	s.Condition = Intact
+}
+
+// checkPressure records the pressure of c, moves it along
+// Intact -> Warning -> Leaking -> Burst and lets out what a damaged
+// component loses over dt. Damage only goes away with Repair.
+func (s *System) checkPressure(c Component, dt float64) {
+	st := c.GetStructurals()
+	if st == nil {
+		return
+	}
+	st.Pressure = Pressure(c)
+
+	if st.MaxPressure > 0 && st.Condition < Burst {
+		ratio := st.Pressure / st.MaxPressure
+		if ratio > 1 {
+			st.Damage = math.Min(1, st.Damage+DamageRate*(ratio-1)*dt)
+		}
+		switch {
+		case st.Damage >= 1:
+			st.Condition = Burst
+		case st.Damage >= LeakDamage:
+			st.Condition = Leaking
+		case ratio >= WarnRatio:
+			st.Condition = Warning
+		default:
+			st.Condition = Intact
+		}
+	}
+
+	var rate float64
+	switch st.Condition {
+	case Leaking:
+		rate = LeakRate
+	case Burst:
+		rate = BurstRate
+	default:
+		return
+	}
+
+	share := math.Min(1, rate*dt)
+	contents, amounts := st.portions()
+	lost := make([]float64, len(amounts))
+	for i := range amounts {
+		lost[i] = amounts[i] * share
+	}
+	for i, m := range append([]MaterialDef(nil), contents...) {
+		if lost[i] < minLeakMass {
+			continue
+		}
+		s.remove(c, m, lost[i])
+		st.AddMaterial(m, -lost[i])
+		s.addLeak(c, m, lost[i])
+	}
+}
+
+// addLeak records mass of m escaping from c, merged with any earlier leak
+// of the same material that has not been collected yet.
+func (s *System) addLeak(c Component, m MaterialDef, mass float64) {
+	for i := range s.Leaks {
+		if s.Leaks[i].Component == c && sameMaterial(s.Leaks[i].Material, m) {
+			s.Leaks[i].Mass += mass
+			return
+		}
+	}
+	s.Leaks = append(s.Leaks, Leak{Component: c, Material: m, Mass: mass})
+}
+
+// TakeLeaks returns the leaks recorded since the last call and forgets them.
+func (s *System) TakeLeaks() []Leak {
+	leaks := s.Leaks
+	s.Leaks = nil
+	return leaks
+}
+
+// Damaged returns every component of the network that is leaking or burst.
+func (s *System) Damaged() []Component {
+	var hurt []Component
+	for _, c := range s.Network() {
+		if c.GetStructurals().Condition >= Leaking {
+			hurt = append(hurt, c)
+		}
+	}
+	return hurt
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"math"
+	"slices"
+)
+
+// Furnace burns solid fuel and heats Target with what it releases. Fuel is
+// any solid with a HeatingValue, fed in by a Feeder or conveyor. Each
+// kilogram burnt leaves its AshFraction behind as Ash and sends the rest up
+// the stack, counted in Exhausted.
+type Furnace struct {
+	Basics
+	Structurals           // Fuel and ash held in the firebox
+	Target      Component `json:"-"`
+	MaxBurn     float64   // kg/s of fuel at full firing
+	Firing      float64   // 0-1 share of MaxBurn
+	Efficiency  float64   // Share of the released heat that reaches Target
+	Output      float64   // W delivered to Target on the last step
+	Burnt       float64   // kg of fuel burnt on the last step
+	Exhausted   float64   // kg of exhaust sent up the stack in total
+}
+
+func (f *Furnace) GetStructurals() *Structurals {
+	return &f.Structurals
+}
+
+// SetFiring sets the firing rate, clamped to 0-1.
+func (f *Furnace) SetFiring(firing float64) {
+	f.Firing = math.Max(0, math.Min(1, firing))
+}
+
+// Toggle puts out a lit furnace and fully fires a cold one.
+func (f *Furnace) Toggle() {
+	if f.Firing > 0 {
+		f.Firing = 0
+	} else {
+		f.Firing = 1
+	}
+}
+
+// Fuel returns the kilograms of fuel left in the firebox.
+func (f *Furnace) Fuel() float64 {
+	contents, amounts := f.portions()
+	fuel := 0.0
+	for i, m := range contents {
+		if m.Type == TypeSolid && m.HeatingValue > 0 {
+			fuel += amounts[i]
+		}
+	}
+	return fuel
+}
+
+// BurnTime returns the seconds the fuel left lasts at the current firing,
+// or +Inf when the furnace is not burning.
+func (f *Furnace) BurnTime() float64 {
+	rate := f.MaxBurn * f.Firing
+	if rate <= 0 {
+		return math.Inf(1)
+	}
+	return f.Fuel() / rate
+}
+
+// ClearAsh empties the ash out of the firebox and returns its mass.
+func (f *Furnace) ClearAsh() float64 {
+	ash := f.AmountOf(Ash)
+	f.AddMaterial(Ash, -ash)
+	return ash
+}
+
+// Update burns fuel for dt seconds and queues the heat for Target.
+func (f *Furnace) Update(s *System, dt float64) {
+	f.Output, f.Burnt = 0, 0
+	if f.Firing <= 0 || f.MaxBurn <= 0 || dt <= 0 {
+		return
+	}
+
+	left := f.MaxBurn * f.Firing * dt
+	heat := 0.0
+	for _, m := range append([]MaterialDef(nil), f.Contents...) {
+		if m.Type != TypeSolid || m.HeatingValue <= 0 || left <= 0 {
+			continue
+		}
+		burn := math.Min(left, f.AmountOf(m))
+		if burn <= 0 {
+			continue
+		}
+		left -= burn
+		ash := burn * m.AshFraction
+		s.remove(f, m, burn)
+		s.account(&Ash, ash, internalEnergy(Ash, ash, f.CurrentHeat))
+		f.AddMaterial(m, -burn)
+		f.AddMaterial(Ash, ash)
+		f.Exhausted += burn - ash
+		f.Burnt += burn
+		heat += burn * m.HeatingValue
+	}
+
+	// Queued heat is only applied across the network. Heat meant for
+	// anything else goes up the stack with the losses, see Validate.
+	if f.Target == nil || heat <= 0 || !slices.Contains(s.Network(), f.Target) {
+		return
+	}
+	delivered := heat * f.Efficiency
+	f.Target.GetStructurals().QueueHeat(delivered)
+	s.account(nil, 0, delivered)
+	f.Output = delivered / dt
+}
@@ -1,11 +1,15 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type Game struct {
@@ -17,11 +21,12 @@ type Game struct {
 	camScaleTo           float64
 	mousePanX, mousePanY int
 	offscreen            *ebiten.Image
-	pause                bool
+	selected             *Entity
 }
 
+// SetPause pauses or resumes the simulation clock.
 func (g *Game) SetPause(p bool) {
-	g.pause = p
+	g.System.Clock.Paused = p
 }
 
 func NewGame() (*Game, error) {
@@ -37,23 +42,68 @@ func NewGame() (*Game, error) {
 		camScaleTo:   1,
 		mousePanX:    0,
 		mousePanY:    0,
-		pause:        true,
 	}
 	l, err := NewLevel(g)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new level: %s", err)
 	}
 	g.currentLevel = l
+	g.SetPause(true)
 	return g, nil
 }
 
+// SetLevel swaps in l and its System, paused.
+func (g *Game) SetLevel(l *Level) {
+	g.currentLevel = l
+	g.System = l.System
+	g.selected = nil
+	g.SetPause(true)
+}
+
 func (g *Game) Update() error {
-	if !g.pause {
-		g.System.Tick()
+	tps := ebiten.TPS()
+	if tps <= 0 {
+		tps = TicksPerSecond
+	}
+	g.System.Advance(1.0 / float64(tps))
+
+	// Simulation clock controls.
+	clock := &g.System.Clock
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		clock.Paused = !clock.Paused
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyP) {
-		g.pause = !g.pause
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		clock.Faster()
 	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		clock.Slower()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+		g.System.StepN(1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyComma) {
+		g.System.StepN(10)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		if err := g.SaveTo(QuickSave); err != nil {
+			Logger.Error("quick save failed", "err", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		if err := g.LoadFrom(QuickSave); err != nil {
+			Logger.Error("quick load failed", "err", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		if g.System.Audit == nil {
+			g.System.Audit = &Auditor{}
+		} else {
+			g.System.Audit = nil
+		}
+	}
+	g.currentLevel.CollectLeaks()
+
+	g.updateSelection()
 
 	// Target scroll zoom level.
 	var scrollY float64
@@ -130,6 +180,15 @@ func (g *Game) Update() error {
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.renderLevel(screen)
+
+	clock := g.System.Clock
+	state := "RUN"
+	if clock.Paused {
+		state = "PAUSE"
+	}
+	grid := &g.System.Grid
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("%s %.1fx  T %.1fs  STEP %d\nPOWER %.0f/%.0f W  UNSERVED %.0f W\nP pause  [ ] speed  . , step 1/10  F3 audit  F5 save  F9 load\n%s%s%s",
+		state, clock.SpeedFactor(), clock.Time, clock.Steps, grid.Supply(), grid.Demand(), grid.Unserved(), g.selectionStatus(), g.validationStatus(), g.auditStatus()))
 	// ebitenutil.DebugPrint(screen, fmt.Sprintf("Fill: %.1f", g.System.Nodes[0].GetStructurals().CurrentCapacity))
 
 	// ebitenutil.DebugPrint(screen, fmt.Sprintf("KEYS WASD EC R\nFPS  %0.0f\nTPS  %0.0f\nSCA  %0.2f\nPOS  %0.0f,%0.0f", ebiten.ActualFPS(), ebiten.ActualTPS(), g.camScale, g.camX, g.camY))
@@ -154,6 +213,172 @@ func (g *Game) IsoToCartesian(x, y float64) (float64, float64) {
 	return cx, cy
 }
 
+// ScreenToTile returns the tile under screen position sx, sy.
+func (g *Game) ScreenToTile(sx, sy int) (int, int) {
+	cx, cy := float64(g.w/2), float64(g.h/2)
+	xi := (float64(sx)-cx)/g.camScale + g.camX
+	yi := (float64(sy)-cy)/g.camScale - g.camY
+
+	// Tile sprites are drawn from their top-left corner, the diamond's
+	// centre sits half a tile across and a quarter down.
+	half := float64(g.currentLevel.tileSize / 2)
+	quarter := float64(g.currentLevel.tileSize / 4)
+	x, y := g.IsoToCartesian(xi-half, yi-quarter)
+	return int(math.Round(x)), int(math.Round(y))
+}
+
+// Select makes the top-most controllable entity on tile x, y the target of
+// the control keys, or clears the selection if there is none.
+func (g *Game) Select(x, y int) {
+	g.selected = nil
+	t := g.currentLevel.Tile(x, y)
+	if t == nil {
+		return
+	}
+	for _, e := range t.Entities() {
+		switch e.Component.(type) {
+		case *Valve, *Pump, *Furnace, *Reactor:
+			g.selected = e
+			return
+		}
+		// Damaged components can be picked for repair.
+		if s := e.Component.GetStructurals(); s != nil && s.Condition >= Leaking {
+			g.selected = e
+		}
+	}
+}
+
+// Demolish despawns the selected component, spilling what it held onto the
+// floor, and clears the selection.
+func (g *Game) Demolish() error {
+	if g.selected == nil {
+		return nil
+	}
+	_, err := g.currentLevel.Despawn(g.selected.Component, SpillContents)
+	g.selected = nil
+	return err
+}
+
+// Selected returns the entity the control keys act on, or nil.
+func (g *Game) Selected() *Entity {
+	return g.selected
+}
+
+// updateSelection picks an entity with the left mouse button and applies
+// the control keys to it. O opens/shuts a valve, switches a pump on/off,
+// lights/puts out a furnace or scrams a reactor. - and = step a valve's
+// opening, a pump's speed, a furnace's firing or a reactor's rods by 10%, R
+// repairs a damaged component and Delete demolishes it.
+func (g *Game) updateSelection() {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		g.Select(g.ScreenToTile(ebiten.CursorPosition()))
+	}
+	if g.selected == nil {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		if s := g.selected.Component.GetStructurals(); s != nil {
+			s.Repair()
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
+		if err := g.Demolish(); err != nil {
+			Logger.Error("demolish failed", "err", err)
+		}
+		return
+	}
+
+	step := 0.0
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+		step = 0.1
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+		step = -0.1
+	}
+	toggle := inpututil.IsKeyJustPressed(ebiten.KeyO)
+
+	switch c := g.selected.Component.(type) {
+	case *Valve:
+		if toggle {
+			c.Toggle()
+		}
+		c.SetOpening(c.Opening + step)
+	case *Pump:
+		if toggle {
+			c.SetOn(!c.On)
+		}
+		c.SetSpeed(c.Speed + step)
+	case *Furnace:
+		if toggle {
+			c.Toggle()
+		}
+		c.SetFiring(c.Firing + step)
+	case *Reactor:
+		if toggle {
+			c.Scram()
+		}
+		if step != 0 {
+			c.SetRods(c.Rods - step)
+		}
+	}
+}
+
+// validationStatus lists the layout problems System.Validate found when
+// the level last changed, for the debug overlay.
+func (g *Game) validationStatus() string {
+	var issues ValidationErrors
+	if g.currentLevel == nil || !errors.As(g.currentLevel.Issues(), &issues) {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%d LAYOUT ISSUES", len(issues))
+	for i, e := range issues {
+		if i == 3 {
+			fmt.Fprintf(&b, "\n  ... %d more", len(issues)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s", e)
+	}
+	return b.String()
+}
+
+// auditStatus reports the conservation auditor, toggled with F3, for the
+// debug overlay.
+func (g *Game) auditStatus() string {
+	a := g.System.Audit
+	if a == nil {
+		return ""
+	}
+	if a.Drifted == 0 {
+		return "\nAUDIT OK"
+	}
+	return fmt.Sprintf("\nAUDIT %d STEPS DRIFTED\n  first %v", a.Drifted, a.Drifts[0])
+}
+
+// selectionStatus describes the selected entity for the debug overlay.
+func (g *Game) selectionStatus() string {
+	if g.selected == nil {
+		return "click a valve, pump, furnace, reactor or damaged part to select"
+	}
+	switch c := g.selected.Component.(type) {
+	case *Valve:
+		return fmt.Sprintf("VALVE %s %.0f%%  O shut/open  - = adjust", c.Identifier, c.Opening*100)
+	case *Pump:
+		state := "OFF"
+		if c.On {
+			state = "ON"
+		}
+		return fmt.Sprintf("PUMP %s %s %.0f%%  O on/off  - = speed", c.Identifier, state, c.Speed*100)
+	case *Furnace:
+		return fmt.Sprintf("FURNACE %s %.0f%% FUEL %.0f kg  O light/out  - = firing", c.Identifier, c.Firing*100, c.Fuel())
+	case *Reactor:
+		return fmt.Sprintf("REACTOR %s %.0f MW RODS %.0f%%  O scram  - = rods", c.Identifier, c.Output/1e6, c.Rods*100)
+	}
+	if s := g.selected.Component.GetStructurals(); s != nil && s.Condition >= Leaking {
+		return fmt.Sprintf("DAMAGED %s %.0f%%  R repair", strings.ToUpper(s.Condition.String()), s.Damage*100)
+	}
+	return ""
+}
+
 func (g *Game) renderLevel(screen *ebiten.Image) {
 	op := &ebiten.DrawImageOptions{}
 	padding := float64(g.currentLevel.tileSize) * g.camScale
@@ -0,0 +1,125 @@
+package game
+
+import "math"
+
+const (
+	ZeroCelsius      = 273.15 // K
+	DefaultHeatRatio = 1.4    // cp/cv of a diatomic gas such as air
+)
+
+// Ideal gas bookkeeping for Structurals.
+//
+// Gases are compressible, so they take no fixed share of a vessel. They
+// spread through whatever MaxVolume is left by liquids and solids, and their
+// pressure follows P = m R T / V with GasConstant as the specific gas
+// constant R in J/(kg·K).
+
+// Kelvin converts a temperature in °C to K, never going below absolute zero.
+func Kelvin(celsius float64) float64 {
+	return math.Max(0, celsius+ZeroCelsius)
+}
+
+// freeVolume returns the space left for gas in s. A sliver is always kept so
+// a brim-full vessel stays finite.
+func (s *Structurals) freeVolume() float64 {
+	return math.Max(s.MaxVolume-s.Volume(), 0.01*s.MaxVolume)
+}
+
+// gas returns the mass of gas held by s, the sum of mass times GasConstant
+// and the mass-weighted HeatRatio.
+func (s *Structurals) gas() (mass, mR, gamma float64) {
+	contents, amounts := s.portions()
+	for i, m := range contents {
+		if m.Type != TypeGas {
+			continue
+		}
+		g := m.HeatRatio
+		if g <= 1 {
+			g = DefaultHeatRatio
+		}
+		mass += amounts[i]
+		mR += amounts[i] * m.GasConstant
+		gamma += amounts[i] * g
+	}
+	if mass > 0 {
+		gamma /= mass
+	}
+	return mass, mR, gamma
+}
+
+// GasPressure returns the absolute pressure in Pa of the gas held by c, or
+// zero when it holds none or has no fixed volume for the gas to fill.
+func GasPressure(c Component) float64 {
+	if c == nil || c.GetStructurals() == nil {
+		return 0
+	}
+	s := c.GetStructurals()
+	if s.MaxVolume <= 0 {
+		return 0
+	}
+	_, mR, _ := s.gas()
+	return mR * Kelvin(s.CurrentHeat) / s.freeVolume()
+}
+
+// GasDensity returns the density of the gas held by c, derived from how much
+// of it fills the free volume. Components with no fixed volume, or holding no
+// gas, report the listed Density of their first gas.
+func GasDensity(c Component) float64 {
+	s := c.GetStructurals()
+	if mass, _, _ := s.gas(); mass > 0 && s.MaxVolume > 0 {
+		return mass / s.freeVolume()
+	}
+	for _, m := range s.Contents {
+		if m.Type == TypeGas && m.Density > 0 {
+			return m.Density
+		}
+	}
+	return Steam.Density
+}
+
+// outflow returns the density of what leaves c first and whether it is a
+// gas. Well-mixed components give up their mix. Stratified ones give up
+// their densest material first, or their lightest when DrawTop is set, the
+// same order drawFractions uses.
+func outflow(c Component) (density float64, gas bool) {
+	s := c.GetStructurals()
+	if s.Stratified {
+		contents, amounts := s.portions()
+		first := -1
+		for i, m := range contents {
+			if amounts[i] <= 0 || m.Type == TypeSolid {
+				continue
+			}
+			if first < 0 ||
+				(s.DrawTop && m.Density < contents[first].Density) ||
+				(!s.DrawTop && m.Density > contents[first].Density) {
+				first = i
+			}
+		}
+		if first >= 0 {
+			if m := contents[first]; m.Type == TypeGas {
+				return GasDensity(c), true
+			} else if m.Density > 0 {
+				return m.Density, false
+			}
+		}
+	}
+	return MixDensity(c), GetMaterial(c).Type == TypeGas
+}
+
+// chokedFlow returns the most gas in kg/s that can leave c through area.
+// Past this the gas reaches the speed of sound in the opening and a lower
+// downstream pressure no longer speeds it up.
+func chokedFlow(c Component, area float64) float64 {
+	s := c.GetStructurals()
+	mass, mR, gamma := s.gas()
+	p := GasPressure(c)
+	if mass <= 0 || p <= 0 {
+		return math.Inf(1)
+	}
+	rt := mR / mass * Kelvin(s.CurrentHeat)
+	if rt <= 0 {
+		return math.Inf(1)
+	}
+	return area * p * math.Sqrt(gamma/rt) * math.Pow(2/(gamma+1), (gamma+1)/(2*(gamma-1)))
+}
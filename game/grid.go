@@ -0,0 +1,178 @@
+package game
+
+import "slices"
+
+// PowerSource is implemented by components that put power on the grid.
+type PowerSource interface {
+	Component
+	PowerOutput() float64
+}
+
+// PowerSink is implemented by components that draw power from the grid.
+type PowerSink interface {
+	Component
+	PowerDemand() float64
+	Supply(w float64)
+}
+
+// GridDevice is a producer or consumer attached to the grid at a tile.
+type GridDevice struct {
+	Component Component
+	X, Y      int
+}
+
+// PowerNetwork is a set of wires joined tile to tile, with every device
+// touching them. A device touching no wire sits in a network of its own.
+type PowerNetwork struct {
+	Wires    []*Wire
+	Devices  []*GridDevice
+	Supply   float64 // W offered by sources
+	Demand   float64 // W wanted by sinks
+	Served   float64 // W delivered to sinks
+	Unserved float64 // W of demand left unmet
+}
+
+// Grid is the electrical network, kept alongside the hydraulic one.
+type Grid struct {
+	Wires    []*Wire
+	Devices  []*GridDevice
+	Networks []*PowerNetwork // Result of the last Balance
+}
+
+// AddWire lays a wire on the grid.
+func (g *Grid) AddWire(w *Wire) {
+	if w != nil {
+		g.Wires = append(g.Wires, w)
+	}
+}
+
+// Attach connects a producer or consumer at tile x, y. Components that
+// neither produce nor consume power are ignored.
+func (g *Grid) Attach(c Component, x, y int) {
+	_, src := c.(PowerSource)
+	_, sink := c.(PowerSink)
+	if !src && !sink {
+		return
+	}
+	g.Devices = append(g.Devices, &GridDevice{Component: c, X: x, Y: y})
+}
+
+// Detach takes c off the grid, whether it is a wire or a device. Networks
+// keep it until the next Balance.
+func (g *Grid) Detach(c Component) {
+	g.Wires = slices.DeleteFunc(g.Wires, func(w *Wire) bool { return Component(w) == c })
+	g.Devices = slices.DeleteFunc(g.Devices, func(d *GridDevice) bool { return d.Component == c })
+}
+
+// Balance matches supply to demand on every network. When there is not
+// enough power, every sink gets the same share of what it asked for.
+func (g *Grid) Balance() {
+	g.Networks = g.networks()
+	for _, n := range g.Networks {
+		n.Supply, n.Demand = 0, 0
+		for _, d := range n.Devices {
+			if src, ok := d.Component.(PowerSource); ok {
+				n.Supply += src.PowerOutput()
+			}
+			if sink, ok := d.Component.(PowerSink); ok {
+				n.Demand += sink.PowerDemand()
+			}
+		}
+
+		share := 1.0
+		if n.Demand > n.Supply {
+			share = n.Supply / n.Demand
+		}
+		n.Served = n.Demand * share
+		n.Unserved = n.Demand - n.Served
+		for _, d := range n.Devices {
+			if sink, ok := d.Component.(PowerSink); ok {
+				sink.Supply(sink.PowerDemand() * share)
+			}
+		}
+	}
+}
+
+// Supply returns the power offered across every network on the last Balance.
+func (g *Grid) Supply() float64 {
+	total := 0.0
+	for _, n := range g.Networks {
+		total += n.Supply
+	}
+	return total
+}
+
+// Demand returns the power wanted across every network on the last Balance.
+func (g *Grid) Demand() float64 {
+	total := 0.0
+	for _, n := range g.Networks {
+		total += n.Demand
+	}
+	return total
+}
+
+// Unserved returns the demand left unmet across every network on the last
+// Balance.
+func (g *Grid) Unserved() float64 {
+	total := 0.0
+	for _, n := range g.Networks {
+		total += n.Unserved
+	}
+	return total
+}
+
+// networks groups wires that touch edge to edge or share a tile, then hangs each device on
+// the network of a wire on its own or a neighbouring tile.
+func (g *Grid) networks() []*PowerNetwork {
+	type cell struct{ x, y int }
+	// Every wire is kept, wires sharing a tile join the same network.
+	at := make(map[cell][]*Wire, len(g.Wires))
+	for _, w := range g.Wires {
+		at[cell{w.X, w.Y}] = append(at[cell{w.X, w.Y}], w)
+	}
+
+	neighbours := func(x, y int) []cell {
+		return []cell{{x, y}, {x + 1, y}, {x - 1, y}, {x, y + 1}, {x, y - 1}}
+	}
+
+	var nets []*PowerNetwork
+	owner := make(map[*Wire]*PowerNetwork, len(g.Wires))
+	for _, w := range g.Wires {
+		if owner[w] != nil {
+			continue
+		}
+		n := &PowerNetwork{}
+		nets = append(nets, n)
+		queue := []*Wire{w}
+		owner[w] = n
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			n.Wires = append(n.Wires, cur)
+			for _, c := range neighbours(cur.X, cur.Y) {
+				for _, next := range at[c] {
+					if owner[next] == nil {
+						owner[next] = n
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+	}
+
+	for _, d := range g.Devices {
+		var n *PowerNetwork
+		for _, c := range neighbours(d.X, d.Y) {
+			if wires := at[c]; len(wires) > 0 {
+				n = owner[wires[0]]
+				break
+			}
+		}
+		if n == nil {
+			n = &PowerNetwork{}
+			nets = append(nets, n)
+		}
+		n.Devices = append(n.Devices, d)
+	}
+	return nets
+}
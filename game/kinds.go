@@ -0,0 +1,487 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The component kinds that come with the game.
+func init() {
+	RegisterComponent("Reservoir", ComponentKind{
+		Defaults: defaultVolume(1000.0),
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			res := &Reservoir{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 0, 255}, // Default blue-ish
+				},
+				Structurals: c.structurals(c.initialContents(Water)),
+			}
+			// Create Reservoir Entity (visuals)
+			return res, NewReservoirEntity(c.X, c.Y, res, 1), nil
+		},
+	})
+
+	RegisterComponent("Boiler", ComponentKind{
+		Defaults: defaultVolume(1000.0),
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			b := &Boiler{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{200, 60, 0},
+				},
+				Structurals: c.structurals(c.initialContents(Water)),
+				HeatInput:   c.HeatInput,
+			}
+			b.Stratified = true
+			b.DrawTop = true
+			// No boiler art yet, reuse the reservoir fill states.
+			return b, NewReservoirEntity(c.X, c.Y, b, 1), nil
+		},
+	})
+
+	RegisterComponent("Generator", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			defaultVolume(10.0)(c) // Just the turbine casing
+			if c.Efficiency == 0 {
+				c.Efficiency = 0.9
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			g := &Generator{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{255, 200, 0},
+				},
+				Structurals: c.structurals(c.initialContents(Water)),
+				Efficiency:  c.Efficiency,
+				Load:        c.Load,
+			}
+			return g, NewGeneratorEntity(c.X, c.Y, g, 5), nil
+		},
+	})
+
+	RegisterComponent("WaterTurbine", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			defaultVolume(10.0)(c)
+			if c.Efficiency == 0 {
+				c.Efficiency = 0.93
+			}
+			if c.RatedFlow == 0 {
+				c.RatedFlow = 1.0
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			g := &Generator{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 120, 255},
+				},
+				Structurals: c.structurals(c.initialContents(Water)),
+				Efficiency:  c.Efficiency,
+				Load:        c.Load,
+				RatedFlow:   c.RatedFlow,
+			}
+			return g, NewGeneratorEntity(c.X, c.Y, g, 5), nil
+		},
+	})
+
+	RegisterComponent("Junction", ComponentKind{
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			contents := []MaterialDef{Water}
+			if c.Contents != nil {
+				contents = []MaterialDef{*c.Contents}
+			}
+			j := &Junction{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{120, 120, 120},
+				},
+				Structurals: Structurals{
+					Contents:    contents,
+					CurrentHeat: c.temperature(),
+					MaxHeat:     c.MaxHeat,
+					MaxPressure: c.MaxPressure,
+					IsJunction:  true,
+				},
+			}
+			return j, NewPipeEntity(c.X, c.Y, j, "junction", 10), nil
+		},
+	})
+
+	RegisterComponent("Outfall", ComponentKind{
+		Defaults: defaultVolume(1e6), // Takes anything
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			o := &Outfall{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 80, 160},
+				},
+				Structurals: Structurals{
+					MaxVolume:   c.MaxVolume,
+					Area:        c.Area,
+					Contents:    []MaterialDef{Water},
+					CurrentHeat: c.temperature(),
+				},
+			}
+			return o, NewEntity(c.X, c.Y, o, StaticSpriteSelector("outfall"), 1), nil
+		},
+	})
+
+	RegisterComponent("Consumer", ComponentKind{
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			con := &Consumer{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 200, 0},
+				},
+				Demand: c.Demand,
+			}
+			return con, NewEntity(c.X, c.Y, con, StaticSpriteSelector("consumer"), 5), nil
+		},
+		Register: registerNothing, // Electrical only, nothing flows through it.
+	})
+
+	RegisterComponent("Wire", ComponentKind{
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			w := &Wire{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{50, 50, 50},
+				},
+				X: c.X,
+				Y: c.Y,
+			}
+			return w, NewEntity(c.X, c.Y, w, StaticSpriteSelector("wire"), 2), nil
+		},
+		Register: func(s *System, comp Component) {
+			s.Grid.AddWire(comp.(*Wire))
+		},
+	})
+
+	RegisterComponent("Pump", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			if c.PumpHead == 0 {
+				c.PumpHead = 20.0
+			}
+			if c.PumpFlow == 0 {
+				c.PumpFlow = 0.5
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			// The pump is left unattached, set Pump.Pipe to drive a pipe.
+			pump := &Pump{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 150, 150},
+				},
+				ShutoffHead: c.PumpHead,
+				MaxFlow:     c.PumpFlow,
+				On:          true,
+				Speed:       1,
+				RatedPower:  c.RatedPower,
+			}
+			return pump, NewEntity(c.X, c.Y, pump, StaticSpriteSelector("pump"), 6), nil
+		},
+		Register: registerMachine,
+		Connect: func(comp, from, to Component) error {
+			p, err := pipeTarget("Pump", from, to)
+			if err != nil {
+				return err
+			}
+			comp.(*Pump).Pipe = p
+			return nil
+		},
+	})
+
+	RegisterComponent("Valve", ComponentKind{
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			v := &Valve{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{150, 0, 150},
+				},
+				Opening: 1,
+			}
+			if c.Opening != nil {
+				v.SetOpening(*c.Opening)
+			}
+			// The valve is left unfitted, use Valve.Fit to install it on a pipe.
+			return v, NewValveEntity(c.X, c.Y, v, 11), nil
+		},
+		Register: registerNothing, // Reached through the pipe it is fitted to.
+		Connect: func(comp, from, to Component) error {
+			p, err := pipeTarget("Valve", from, to)
+			if err != nil {
+				return err
+			}
+			comp.(*Valve).Fit(p)
+			return nil
+		},
+	})
+
+	RegisterComponent("Hopper", ComponentKind{
+		Defaults: defaultVolume(100.0),
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			h := &Hopper{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{60, 60, 60},
+				},
+				Structurals: c.structurals(c.initialContents(Coal)), // Hoppers default to coal
+			}
+			return h, NewEntity(c.X, c.Y, h, StaticSpriteSelector("hopper"), 5), nil
+		},
+	})
+
+	RegisterComponent("Conveyor", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			if c.Throughput == 0 {
+				c.Throughput = 10.0
+			}
+			if c.BeltSpeed == 0 {
+				c.BeltSpeed = 1.0
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			// The belt is left unattached, set Conveyor.From and To to use it.
+			belt := &Conveyor{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{90, 90, 90},
+				},
+				Structurals: Structurals{CurrentHeat: c.temperature()},
+				Length:      float64(1 + len(c.Path)),
+				Speed:       c.BeltSpeed,
+				Throughput:  c.Throughput,
+			}
+			// The rest of the belt shares the same component.
+			for _, t := range c.Path {
+				l.AddEntity(NewEntity(t[0], t[1], belt, StaticSpriteSelector("conveyor"), 2))
+			}
+			return belt, NewEntity(c.X, c.Y, belt, StaticSpriteSelector("conveyor"), 2), nil
+		},
+		Register: registerMachine,
+		Connect: func(comp, from, to Component) error {
+			for _, end := range []Component{from, to} {
+				if _, ok := end.(*Pipe); ok {
+					return fmt.Errorf("a belt cannot load from or onto pipe %s", Identifier(end))
+				}
+			}
+			belt := comp.(*Conveyor)
+			belt.From, belt.To = from, to
+			return nil
+		},
+	})
+
+	RegisterComponent("Feeder", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			if c.Throughput == 0 {
+				c.Throughput = 1.0
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			// The feeder is left unattached, set Feeder.Source and Target to use it.
+			f := &Feeder{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{120, 80, 40},
+				},
+				Structurals: Structurals{CurrentHeat: c.temperature()},
+				Rate:        c.Throughput,
+				On:          true,
+			}
+			return f, NewEntity(c.X, c.Y, f, StaticSpriteSelector("feeder"), 6), nil
+		},
+		Register: registerMachine,
+		Connect: func(comp, from, to Component) error {
+			f := comp.(*Feeder)
+			f.Source, f.Target = from, to
+			return nil
+		},
+	})
+
+	RegisterComponent("Furnace", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			defaultVolume(5.0)(c)
+			if c.BurnRate == 0 {
+				c.BurnRate = 0.1
+			}
+			if c.Efficiency == 0 {
+				c.Efficiency = 0.8
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			// The furnace is left unattached, set Furnace.Target to heat something.
+			f := &Furnace{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{255, 100, 0},
+				},
+				Structurals: Structurals{
+					MaxVolume:   c.MaxVolume,
+					Area:        c.Area,
+					Quantity:    c.InitialQty,
+					Contents:    c.initialContents(Coal),
+					CurrentHeat: c.temperature(),
+					MaxHeat:     c.MaxHeat,
+				},
+				MaxBurn:    c.BurnRate,
+				Firing:     1,
+				Efficiency: c.Efficiency,
+			}
+			return f, NewFurnaceEntity(c.X, c.Y, f, 5), nil
+		},
+		Register: registerMachine,
+		Connect: func(comp, from, to Component) error {
+			if from != nil {
+				return errors.New("Furnace takes no From")
+			}
+			comp.(*Furnace).Target = to
+			return nil
+		},
+	})
+
+	RegisterComponent("Reactor", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			defaultVolume(100.0)(c)
+			if c.RatedPower == 0 {
+				c.RatedPower = 1e7
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			r := &Reactor{
+				Basics: Basics{
+					Identifier: c.Identifier,
+					Color:      [3]byte{0, 255, 100},
+				},
+				Structurals: c.structurals(c.initialContents(Water)),
+				RatedPower:  c.RatedPower,
+				Rods:        1,
+				RodWorth:    0.03,
+				Excess:      0.005, // Below the delayed fraction, never prompt critical
+				TempCoeff:   -5e-5,
+				RefTemp:     AmbientTemp,
+			}
+			if c.Rods != nil {
+				r.SetRods(*c.Rods)
+			}
+			return r, NewEntity(c.X, c.Y, r, StaticSpriteSelector("reactor"), 5), nil
+		},
+	})
+
+	RegisterComponent("Pipe", ComponentKind{
+		Defaults: func(c *EntityConfig) {
+			if c.PipeLength == 0 {
+				c.PipeLength = 1.0
+			}
+			if c.PipeRadius == 0 {
+				c.PipeRadius = 0.5
+			}
+		},
+		Build: func(l *Level, c EntityConfig) (Component, *Entity, error) {
+			// The pipe is left unconnected, set Pipe.From and To to use it.
+			p := NewPipe(nil, nil, c.PipeLength, c.PipeRadius)
+			p.Identifier = c.Identifier // Pipe usually doesn't show ID, but for debug
+			p.Quantity = c.InitialQty
+			p.CheckValve = c.CheckValve
+			p.CurrentHeat = c.temperature()
+			p.MaxHeat = c.MaxHeat
+			p.MaxPressure = c.MaxPressure
+			sprite := c.Sprite
+			if sprite == "" {
+				sprite = "pipe_horizontal"
+			}
+			return p, NewPipeEntity(c.X, c.Y, p, sprite, 1), nil
+		},
+		Register: func(s *System, comp Component) {
+			s.Pipes = append(s.Pipes, comp.(*Pipe))
+		},
+		Connect: func(comp, from, to Component) error {
+			for _, end := range []Component{from, to} {
+				if !pipeEnd(end) {
+					return fmt.Errorf("a pipe cannot run to or from %s, a %T", Identifier(end), end)
+				}
+			}
+			p := comp.(*Pipe)
+			p.From, p.To = from, to
+			return nil
+		},
+	})
+}
+
+// defaultVolume returns a Defaults that sets MaxVolume to v if it is unset.
+func defaultVolume(v float64) func(c *EntityConfig) {
+	return func(c *EntityConfig) {
+		if c.MaxVolume == 0 {
+			c.MaxVolume = v
+		}
+	}
+}
+
+// pipeTarget returns the pipe named by To that a pump drives or a valve is
+// fitted to.
+func pipeTarget(kind string, from, to Component) (*Pipe, error) {
+	if from != nil {
+		return nil, fmt.Errorf("%s takes no From", kind)
+	}
+	p, ok := to.(*Pipe)
+	if !ok {
+		return nil, fmt.Errorf("%s can only act on a pipe, not %s", kind, Identifier(to))
+	}
+	return p, nil
+}
+
+// pipeEnd reports whether a pipe can run to or from c. Machines, wires,
+// valves and consumers sit beside the pipe network rather than in it.
+func pipeEnd(c Component) bool {
+	switch c.(type) {
+	case *Pump, *Valve, *Wire, *Consumer, *Conveyor, *Feeder, *Furnace:
+		return false
+	}
+	return true
+}
+
+// registerMachine adds a component that acts every step without being part
+// of the pipe graph to System.Machines.
+func registerMachine(s *System, comp Component) {
+	s.Machines = append(s.Machines, comp)
+}
+
+// registerNothing leaves a component out of the System.
+func registerNothing(s *System, comp Component) {}
+
+// temperature returns the temperature a component spawned from c starts at.
+func (c EntityConfig) temperature() float64 {
+	if c.Temperature == nil {
+		return AmbientTemp
+	}
+	return *c.Temperature
+}
+
+// initialContents returns the material a component spawned from c starts
+// with: c.Contents, or fallback if that is not set. It is empty when c has
+// no InitialQty.
+func (c EntityConfig) initialContents(fallback MaterialDef) []MaterialDef {
+	if c.InitialQty <= 0 {
+		return nil
+	}
+	if c.Contents != nil {
+		return []MaterialDef{*c.Contents}
+	}
+	return []MaterialDef{fallback}
+}
+
+// structurals returns the Structurals of a vessel spawned from c holding
+// contents.
+func (c EntityConfig) structurals(contents []MaterialDef) Structurals {
+	return Structurals{
+		MaxVolume:   c.MaxVolume,
+		Area:        c.Area,
+		Quantity:    c.InitialQty,
+		Contents:    contents,
+		CurrentHeat: c.temperature(),
+		MaxHeat:     c.MaxHeat,
+		MaxPressure: c.MaxPressure,
+	}
+}
@@ -1,9 +1,5 @@
 package game
 
-import (
-	"fmt"
-)
-
 // Level represents a Game level.
 type Level struct {
 	Width, Height int
@@ -12,89 +8,48 @@ type Level struct {
 	tileSize int
 	entities []*Entity
 	System   *System
+
+	floor    []string  // Rows of the floor as in LevelFile.Floor
+	spawns   []spawned // Everything made by Spawn, in order
+	issues   error     // What System.Validate found on the last Revalidate
+	building bool      // Being laid out, Spawn leaves validation to the end
+}
+
+// spawned is a component made by Spawn and the config it was made from.
+type spawned struct {
+	config    EntityConfig
+	component Component
 }
 
 func (l *Level) Entities() []*Entity {
 	return l.entities
 }
 
+// NewLevel builds DefaultLevel for g, using g.System if it has one.
 func NewLevel(g *Game) (*Level, error) {
-	l := &Level{
-		Width:    4,
-		Height:   4,
-		tileSize: 32,
-		entities: make([]*Entity, 0),
-	}
-
-	_, err := LoadSpriteSheet(l.tileSize)
+	f, err := ReadLevelFile(DefaultLevel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load spritesheet: %s", err)
+		return nil, err
 	}
-
-	l.tiles = make([][]*Tile, l.Height)
-	for y := 0; y < l.Height; y++ {
-		l.tiles[y] = make([]*Tile, l.Width)
-		for x := 0; x < l.Width; x++ {
-			l.tiles[y][x] = &Tile{}
-
-			floorComp := &Reservoir{
-				Basics: Basics{
-					Identifier: ".",
-					Color:      [3]byte{100, 100, 100},
-				},
-			}
-			floorEntity := NewFloorEntity(x, y, floorComp, 0)
-			l.tiles[y][x].AddEntity(floorEntity)
-			l.entities = append(l.entities, floorEntity)
-		}
-	}
-
 	if g.System == nil {
 		g.System = &System{}
 	}
-	l.System = g.System
-
-	// Add reservoir A
-	entA, _ := l.Spawn(EntityConfig{
-		Type: "Reservoir",
-		X:    1, Y: 1,
-		Identifier: "A",
-		MaxVolume:  2000,
-		InitialQty: 2000,
-		Contents:   &Water,
-	})
-
-	// Add Pipe
-	entPipe, _ := l.Spawn(EntityConfig{
-		Type: "Pipe",
-		X:    1, Y: 2,
-		Identifier: "P1",
-		InitialQty: 0.5, // partial filled
-		PipeLength: 15.0,
-		PipeRadius: 0.5,
-		Sprite:     "pipe_enter_left",
-	})
-
-	// Add reservoir B
-	entB, _ := l.Spawn(EntityConfig{
-		Type: "Reservoir",
-		X:    1, Y: 3,
-		Identifier: "B",
-		MaxVolume:  1500,
-		InitialQty: 0,
-		Contents:   &Water,
-	})
-
-	// Wire up the pipe connection
-	// We need to extract the components from the entities
-	res1 := entA.Component
-	res2 := entB.Component
-	pipe1 := entPipe.Component.(*Pipe)
+	return buildLevel(f, g.System)
+}
 
-	pipe1.From = res1
-	pipe1.To = res2
+// Revalidate runs System.Validate and keeps the result for Issues. Spawn,
+// Despawn and loading call it, anything else changing the network should
+// call it after. Loading validates once, when everything is in place.
+func (l *Level) Revalidate() {
+	l.issues = nil
+	if l.System != nil {
+		l.issues = l.System.Validate()
+	}
+}
 
-	return l, nil
+// Issues returns what System.Validate found on the last Revalidate.
+func (l *Level) Issues() error {
+	return l.issues
 }
 
 // Tile returns the tile at the provided coordinates, or nil.
@@ -109,3 +64,23 @@ func (l *Level) Tile(x, y int) *Tile {
 func (l *Level) Size() (width, height int) {
 	return l.Width, l.Height
 }
+
+// CollectLeaks puts the material leaked by damaged components since the
+// last call onto the tile of the entity holding each component. Leaks from
+// components with no entity in the level are dropped.
+func (l *Level) CollectLeaks() {
+	if l.System == nil {
+		return
+	}
+	for _, leak := range l.System.TakeLeaks() {
+		for _, e := range l.entities {
+			if e.Component != leak.Component {
+				continue
+			}
+			if t := l.Tile(e.X, e.Y); t != nil {
+				t.Spill.AddMaterial(leak.Material, leak.Mass)
+			}
+			break
+		}
+	}
+}
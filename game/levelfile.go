@@ -0,0 +1,231 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultLevel is the level file NewLevel builds.
+const DefaultLevel = "assets/levels/default.json"
+
+// LevelFile is a level as stored on disk.
+type LevelFile struct {
+	Width, Height int
+	// Floor has one row of tiles per line, top first, with '#' for a floor
+	// tile and any other character for bare ground. Every tile gets a floor
+	// if Floor is empty.
+	Floor       []string
+	Entities    []EntityConfig
+	Connections []Connection
+}
+
+// Connection runs the pipe named Pipe from the component named From to the
+// one named To, all by identifier. Unlike EntityConfig.From and To, it can
+// name components listed after the pipe.
+type Connection struct {
+	Pipe, From, To string
+}
+
+// ReadLevelFile reads and decodes the level file at path. Fields the format
+// does not know are reported as errors, so typos do not go unnoticed.
+func ReadLevelFile(path string) (*LevelFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var f LevelFile
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// LoadLevel builds a level, and a new System to go with it, from the level
+// file at path. Every problem found is reported, joined into one error, and
+// no level is returned unless there were none.
+func LoadLevel(path string) (*Level, error) {
+	f, err := ReadLevelFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := buildLevel(f, &System{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// buildLevel lays out f and registers its components with sys.
+func buildLevel(f *LevelFile, sys *System) (*Level, error) {
+	if f.Width <= 0 || f.Height <= 0 {
+		return nil, fmt.Errorf("map size %dx%d is not positive", f.Width, f.Height)
+	}
+	var errs []error
+	if len(f.Floor) > 0 && len(f.Floor) != f.Height {
+		errs = append(errs, fmt.Errorf("floor has %d rows, want %d", len(f.Floor), f.Height))
+	}
+	for y, row := range f.Floor {
+		if n := len([]rune(row)); n != f.Width {
+			errs = append(errs, fmt.Errorf("floor row %d has %d tiles, want %d", y, n, f.Width))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	l, err := newEmptyLevel(f.Width, f.Height, sys, func(x, y int) bool {
+		return len(f.Floor) == 0 || []rune(f.Floor[y])[x] == '#'
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range f.Entities {
+		what := fmt.Sprintf("entity %d (%s %q)", i, c.Type, c.Identifier)
+		outside := false
+		for _, t := range append([][2]int{{c.X, c.Y}}, c.Path...) {
+			if l.Tile(t[0], t[1]) == nil {
+				errs = append(errs, fmt.Errorf("%s: tile %d,%d is outside the %dx%d map", what, t[0], t[1], f.Width, f.Height))
+				outside = true
+			}
+		}
+		if outside {
+			continue
+		}
+		if _, err := l.Spawn(c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+		}
+	}
+
+	for i, cn := range f.Connections {
+		what := fmt.Sprintf("connection %d (%s)", i, cn.Pipe)
+		pc, err := l.Lookup(cn.Pipe)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+			continue
+		}
+		p, ok := pc.(*Pipe)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %q is a %T, not a pipe", what, cn.Pipe, pc))
+			continue
+		}
+		from, errFrom := l.Lookup(cn.From)
+		to, errTo := l.Lookup(cn.To)
+		if errFrom != nil || errTo != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, errors.Join(errFrom, errTo)))
+			continue
+		}
+		// The same checks as for a pipe spawned with From and To.
+		if err := componentKinds["Pipe"].Connect(p, from, to); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", what, err))
+		}
+	}
+
+	// Once everything is in place, separate plants are fine but anything
+	// else Validate finds is not.
+	l.building = false
+	l.Revalidate()
+	if err := l.Issues(); err != nil && len(errs) == 0 {
+		var issues ValidationErrors
+		errors.As(err, &issues)
+		for _, e := range issues {
+			if e.Kind != IsolatedNetwork {
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return l, nil
+}
+
+// newEmptyLevel returns a width by height level for sys with a floor tile
+// wherever floor says so and nothing else on it. The caller lays it out and
+// then clears building and calls Revalidate.
+func newEmptyLevel(width, height int, sys *System, floor func(x, y int) bool) (*Level, error) {
+	l := &Level{
+		Width:    width,
+		Height:   height,
+		tileSize: 32,
+		entities: make([]*Entity, 0),
+		System:   sys,
+		building: true,
+	}
+
+	_, err := LoadSpriteSheet(l.tileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spritesheet: %s", err)
+	}
+
+	l.tiles = make([][]*Tile, l.Height)
+	l.floor = make([]string, l.Height)
+	for y := 0; y < l.Height; y++ {
+		l.tiles[y] = make([]*Tile, l.Width)
+		row := make([]rune, l.Width)
+		for x := 0; x < l.Width; x++ {
+			l.tiles[y][x] = &Tile{}
+			row[x] = '.'
+			if !floor(x, y) {
+				continue
+			}
+			row[x] = '#'
+
+			floorComp := &Reservoir{
+				Basics: Basics{
+					Identifier: ".",
+					Color:      [3]byte{100, 100, 100},
+				},
+			}
+			floorEntity := NewFloorEntity(x, y, floorComp, 0)
+			l.tiles[y][x].AddEntity(floorEntity)
+			l.entities = append(l.entities, floorEntity)
+		}
+		l.floor[y] = string(row)
+	}
+	return l, nil
+}
+
+// entityConfigJSON is how an EntityConfig is written in a level file, with
+// its material named by ID.
+type entityConfigJSON struct {
+	*entityConfigFields
+	Contents string `json:",omitempty"`
+}
+
+type entityConfigFields EntityConfig
+
+// MarshalJSON writes c with Contents as a material ID.
+func (c EntityConfig) MarshalJSON() ([]byte, error) {
+	out := entityConfigJSON{entityConfigFields: (*entityConfigFields)(&c)}
+	if c.Contents != nil {
+		out.Contents = c.Contents.ID
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads c with Contents given as a material ID, such as
+// "water".
+func (c *EntityConfig) UnmarshalJSON(data []byte) error {
+	in := entityConfigJSON{entityConfigFields: (*entityConfigFields)(c)}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return err
+	}
+	c.Contents = nil
+	if in.Contents != "" {
+		m, ok := LookupMaterial(in.Contents)
+		if !ok {
+			return fmt.Errorf("unknown material %q", in.Contents)
+		}
+		c.Contents = m
+	}
+	return nil
+}
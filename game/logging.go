@@ -0,0 +1,74 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LevelTrace is below slog.LevelDebug and logs the state of single
+// components on every step.
+const LevelTrace = slog.LevelDebug - 4
+
+// Logger receives everything the simulation logs. It only writes warnings
+// and errors until SetLogging is called.
+var Logger = newLogger(os.Stderr, slog.LevelWarn)
+
+// traced holds the identifiers of the components logged at LevelTrace, or
+// nil to trace every component.
+var traced map[string]bool
+
+// SetLogging sends simulation logs at level and above to w. Trace messages
+// are only written for the components whose identifiers are listed in
+// trace, or for every component when trace is empty.
+func SetLogging(w io.Writer, level slog.Level, trace ...string) {
+	Logger = newLogger(w, level)
+	traced = nil
+	if len(trace) > 0 {
+		traced = make(map[string]bool, len(trace))
+		for _, id := range trace {
+			traced[id] = true
+		}
+	}
+}
+
+// ParseLogLevel reads a level name such as "warn" or "trace".
+func ParseLogLevel(name string) (slog.Level, error) {
+	if strings.EqualFold(name, "trace") {
+		return LevelTrace, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}))
+}
+
+// tracing reports whether c is logged at LevelTrace. Callers check it
+// before building a message, so tracing costs nothing while it is off.
+func tracing(c Component) bool {
+	if !Logger.Enabled(context.Background(), LevelTrace) {
+		return false
+	}
+	return traced == nil || traced[Identifier(c)]
+}
+
+// trace logs msg about c at LevelTrace.
+func trace(c Component, msg string, args ...any) {
+	Logger.Log(context.Background(), LevelTrace, msg, append([]any{"id", Identifier(c)}, args...)...)
+}
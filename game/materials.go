@@ -13,12 +13,34 @@ type MaterialDef struct {
 	Name         string
 	Type         MaterialType
 	FlowConstant float64
-	Density      float64 // kg/m^3 (or arbitrary game units)
-	GasConstant  float64 // For gases
+	Density      float64 // kg/m^3 (or arbitrary game units), gases at 1 atm
+	GasConstant  float64 // J/(kg·K), specific gas constant of a gas
+	HeatRatio    float64 // cp/cv of a gas, DefaultHeatRatio if zero
+	SpecificHeat float64 // J/(kg·K)
+	LatentHeat   float64 // J/kg taken in when a liquid boils
+	BoilsTo      string  // ID of the gas a liquid boils into
+	CondensesTo  string  // ID of the liquid a gas condenses into
+	HeatingValue float64 // J/kg released when a fuel is burnt
+	AshFraction  float64 // Share of a fuel's mass left as Ash when burnt
 }
 
 var (
-	Water = MaterialDef{ID: "water", Name: "Water", Type: TypeFluid, FlowConstant: 0.5, Density: 1000.0}
-	Steam = MaterialDef{ID: "steam", Name: "Steam", Type: TypeGas, Density: 0.6, GasConstant: 200.0} // density varies, using base
-	Coal  = MaterialDef{ID: "coal", Name: "Coal", Type: TypeSolid, Density: 1500.0}
+	Water = MaterialDef{ID: "water", Name: "Water", Type: TypeFluid, FlowConstant: 0.5, Density: 1000.0, SpecificHeat: 4186.0, LatentHeat: 2.26e6, BoilsTo: "steam"}
+	Steam = MaterialDef{ID: "steam", Name: "Steam", Type: TypeGas, Density: 0.6, GasConstant: 461.5, HeatRatio: 1.33, SpecificHeat: 2010.0, CondensesTo: "water"} // Density at 1 atm and 100°C, see GasDensity
+	Coal  = MaterialDef{ID: "coal", Name: "Coal", Type: TypeSolid, Density: 1500.0, SpecificHeat: 1260.0, HeatingValue: 24e6, AshFraction: 0.1}
+	Ash   = MaterialDef{ID: "ash", Name: "Ash", Type: TypeSolid, Density: 700.0, SpecificHeat: 800.0}
 )
+
+// Materials indexes the built-in materials by ID.
+var Materials = map[string]*MaterialDef{
+	Water.ID: &Water,
+	Steam.ID: &Steam,
+	Coal.ID:  &Coal,
+	Ash.ID:   &Ash,
+}
+
+// LookupMaterial returns the material registered under id.
+func LookupMaterial(id string) (*MaterialDef, bool) {
+	m, ok := Materials[id]
+	return m, ok
+}
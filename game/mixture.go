@@ -0,0 +1,238 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// Per-material bookkeeping for Structurals.
+//
+// Contents lists the materials a component holds and Amounts holds the mass
+// of each, index for index. Quantity is kept equal to the sum of Amounts.
+// Components built with only Contents and Quantity are treated as holding
+// all of Quantity as Contents[0] until the first change is applied.
+
+// sameMaterial reports whether a and b describe the same material.
+func sameMaterial(a, b MaterialDef) bool {
+	if a.ID != "" || b.ID != "" {
+		return a.ID == b.ID
+	}
+	return a == b
+}
+
+// syncAmounts makes Amounts line up with Contents, filling it from Quantity
+// when it is missing.
+func (s *Structurals) syncAmounts() {
+	if len(s.Amounts) == len(s.Contents) && (len(s.Contents) > 0 || s.Quantity == 0) {
+		return
+	}
+	if len(s.Contents) == 0 {
+		s.Contents = []MaterialDef{Water}
+	}
+	amounts := make([]float64, len(s.Contents))
+	copy(amounts, s.Amounts)
+	if len(s.Amounts) == 0 {
+		amounts[0] = s.Quantity
+	}
+	s.Amounts = amounts
+}
+
+// indexOf returns the position of m in Contents, adding it with no mass if
+// it is not there yet.
+func (s *Structurals) indexOf(m MaterialDef) int {
+	s.syncAmounts()
+	for i, c := range s.Contents {
+		if sameMaterial(c, m) {
+			return i
+		}
+	}
+	s.Contents = append(s.Contents, m)
+	s.Amounts = append(s.Amounts, 0)
+	if len(s.PendingAmounts) > 0 {
+		s.PendingAmounts = append(s.PendingAmounts, 0)
+	}
+	return len(s.Contents) - 1
+}
+
+// portions returns the materials held and the mass of each without
+// changing the component.
+func (s *Structurals) portions() ([]MaterialDef, []float64) {
+	if len(s.Amounts) == len(s.Contents) && len(s.Contents) > 0 {
+		return s.Contents, s.Amounts
+	}
+	if len(s.Contents) == 0 {
+		if s.Quantity == 0 {
+			return nil, nil
+		}
+		return []MaterialDef{Water}, []float64{s.Quantity}
+	}
+	amounts := make([]float64, len(s.Contents))
+	amounts[0] = s.Quantity
+	return s.Contents, amounts
+}
+
+// AmountOf returns the mass of m held by the component.
+func (s *Structurals) AmountOf(m MaterialDef) float64 {
+	contents, amounts := s.portions()
+	for i, c := range contents {
+		if sameMaterial(c, m) {
+			return amounts[i]
+		}
+	}
+	return 0
+}
+
+// AddMaterial adds mass of m to the component immediately. A negative mass
+// removes material, never taking more than is held.
+func (s *Structurals) AddMaterial(m MaterialDef, mass float64) {
+	i := s.indexOf(m)
+	s.Amounts[i] = math.Max(0, s.Amounts[i]+mass)
+	s.settle()
+}
+
+// QueueMaterial adds mass of m to the component's pending change, to be
+// applied by ApplyPending.
+func (s *Structurals) QueueMaterial(m MaterialDef, mass float64) {
+	i := s.indexOf(m)
+	if len(s.PendingAmounts) < len(s.Contents) {
+		pending := make([]float64, len(s.Contents))
+		copy(pending, s.PendingAmounts)
+		s.PendingAmounts = pending
+	}
+	s.PendingAmounts[i] += mass
+	s.PendingChange += mass
+}
+
+// Volume returns the space taken by the component's liquids and solids.
+// Gases take none of it, they spread through whatever is left.
+func (s *Structurals) Volume() float64 {
+	contents, amounts := s.portions()
+	vol := 0.0
+	for i, m := range contents {
+		if m.Type == TypeGas {
+			continue
+		}
+		density := m.Density
+		if density == 0 {
+			density = Water.Density
+		}
+		vol += amounts[i] / density
+	}
+	return vol
+}
+
+// settle recomputes Quantity, drops emptied materials and orders Contents
+// by mass so Contents[0] is always the dominant material.
+func (s *Structurals) settle() {
+	s.syncAmounts()
+	if len(s.Contents) == 0 {
+		s.Amounts = nil
+		s.Quantity = 0
+		return
+	}
+
+	idx := make([]int, len(s.Contents))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return s.Amounts[idx[a]] > s.Amounts[idx[b]]
+	})
+
+	contents := make([]MaterialDef, 0, len(idx))
+	amounts := make([]float64, 0, len(idx))
+	total := 0.0
+	for n, i := range idx {
+		// Keep the last dominant material so an empty vessel remembers it.
+		if s.Amounts[i] <= 0 && n > 0 {
+			continue
+		}
+		contents = append(contents, s.Contents[i])
+		amounts = append(amounts, s.Amounts[i])
+		total += s.Amounts[i]
+	}
+	s.Contents = contents
+	s.Amounts = amounts
+	s.Quantity = total
+}
+
+// fluidMass returns the mass of the liquids and gases held, the part that
+// can leave through a pipe.
+func (s *Structurals) fluidMass() float64 {
+	contents, amounts := s.portions()
+	mass := 0.0
+	for i, m := range contents {
+		if m.Type != TypeSolid {
+			mass += amounts[i]
+		}
+	}
+	return mass
+}
+
+// drawFractions returns the share of each entry of Contents in an outflow of
+// total mass. Well-mixed components give up every material in proportion to
+// what they hold. Stratified components give up their densest material first,
+// as if drawn from the bottom of the vessel, or their lightest first when
+// DrawTop is set. Solids never leave this way.
+func (s *Structurals) drawFractions(total float64) []float64 {
+	s.syncAmounts()
+	fractions := make([]float64, len(s.Contents))
+	if total <= 0 || s.Quantity <= 0 {
+		return fractions
+	}
+
+	if !s.Stratified {
+		held := s.fluidMass()
+		if held <= 0 {
+			return fractions
+		}
+		for i, a := range s.Amounts {
+			if s.Contents[i].Type != TypeSolid {
+				fractions[i] = a / held
+			}
+		}
+		return fractions
+	}
+
+	idx := make([]int, len(s.Contents))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		if s.DrawTop {
+			return s.Contents[idx[a]].Density < s.Contents[idx[b]].Density
+		}
+		return s.Contents[idx[a]].Density > s.Contents[idx[b]].Density
+	})
+	left := total
+	for _, i := range idx {
+		if s.Contents[i].Type == TypeSolid {
+			continue
+		}
+		take := math.Min(left, s.Amounts[i])
+		fractions[i] = take / total
+		left -= take
+		if left <= 0 {
+			break
+		}
+	}
+	return fractions
+}
+
+// MixDensity returns the mass-weighted density of the component's contents,
+// or the density of its dominant material when it is empty. Gases count at
+// the density they have in the component, see GasDensity.
+func MixDensity(c Component) float64 {
+	s := c.GetStructurals()
+	vol := s.Volume()
+	if mass, _, _ := s.gas(); mass > 0 {
+		vol += mass / GasDensity(c)
+	}
+	if vol > 0 && s.Quantity > 0 {
+		return s.Quantity / vol
+	}
+	if d := GetMaterial(c).Density; d > 0 {
+		return d
+	}
+	return Water.Density
+}
@@ -0,0 +1,124 @@
+package game
+
+import "math"
+
+const (
+	AtmosphericPressure = 101325.0 // Pa
+	// Antoine coefficients for water, with pressure in mmHg and temperature in °C.
+	antoineA = 8.07131
+	antoineB = 1730.63
+	antoineC = 233.426
+	mmHgToPa = 133.322
+)
+
+// BoilingPoint returns the temperature in °C at which water boils under the
+// given absolute pressure in Pa. It is clamped between the triple point and
+// the critical point.
+func BoilingPoint(pressure float64) float64 {
+	if pressure <= 611.657 {
+		return 0.01
+	}
+	t := antoineB/(antoineA-math.Log10(pressure/mmHgToPa)) - antoineC
+	return math.Min(t, 373.946)
+}
+
+// Pressure returns the absolute pressure in Pa at the bottom of c.
+func Pressure(c Component) float64 {
+	if c == nil || c.GetStructurals() == nil {
+		return AtmosphericPressure
+	}
+	gauge := (TotalHead(c) - c.GetStructurals().BaseElevation) * Water.Density * Gravity
+	return AtmosphericPressure + math.Max(0, gauge)
+}
+
+// changePhase boils or condenses every liquid/gas pair held by c until the
+// contents sit at the boiling point, or one side of the pair runs out.
+// Latent heat is taken from, or given back to, the contents, so sensible
+// plus latent energy is the same before and after.
+func changePhase(c Component) {
+	s := c.GetStructurals()
+	if s == nil || s.Quantity <= 0 {
+		return
+	}
+
+	done := make(map[string]bool)
+	for _, m := range append([]MaterialDef(nil), s.Contents...) {
+		liquid, vapor, ok := phasePair(m)
+		if !ok || done[liquid.ID] {
+			continue
+		}
+		done[liquid.ID] = true
+
+		mass := phaseMass(s, liquid, vapor)
+		if mass == 0 {
+			continue
+		}
+		energy := HeatCapacity(s)*s.CurrentHeat - mass*liquid.LatentHeat
+		s.AddMaterial(liquid, -mass)
+		s.AddMaterial(vapor, mass)
+		if after := HeatCapacity(s); after > 0 {
+			s.CurrentHeat = energy / after
+		}
+	}
+	checkHeat(s)
+}
+
+// phaseMass returns the mass of liquid to boil, or vapor to condense when
+// negative, that leaves s at the boiling point. Boiling raises the gas
+// pressure and with it the boiling point, so the mass is searched for
+// against the pressure the contents end up at.
+func phaseMass(s *Structurals, liquid, vapor MaterialDef) float64 {
+	energy := HeatCapacity(s) * s.CurrentHeat
+	// excess returns how far above its boiling point s would be after
+	// converting mass.
+	excess := func(mass float64) float64 {
+		trial := &Reservoir{Structurals: *s}
+		t := &trial.Structurals
+		t.Contents = append([]MaterialDef(nil), s.Contents...)
+		t.Amounts = append([]float64(nil), s.Amounts...)
+		t.AddMaterial(liquid, -mass)
+		t.AddMaterial(vapor, mass)
+		if after := HeatCapacity(t); after > 0 {
+			t.CurrentHeat = (energy - mass*liquid.LatentHeat) / after
+		}
+		return t.CurrentHeat - BoilingPoint(Pressure(trial))
+	}
+
+	lo, hi := -s.AmountOf(vapor), s.AmountOf(liquid)
+	switch {
+	case excess(0) == 0:
+		return 0
+	case excess(hi) >= 0:
+		return hi
+	case excess(lo) <= 0:
+		return lo
+	}
+	for range 60 {
+		mid := (lo + hi) / 2
+		if excess(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// phasePair returns the liquid and gas forms of m, if it has both.
+func phasePair(m MaterialDef) (liquid, vapor MaterialDef, ok bool) {
+	switch {
+	case m.BoilsTo != "":
+		v, found := LookupMaterial(m.BoilsTo)
+		if !found {
+			return liquid, vapor, false
+		}
+		return m, *v, m.LatentHeat > 0
+	case m.CondensesTo != "":
+		l, found := LookupMaterial(m.CondensesTo)
+		if !found {
+			return liquid, vapor, false
+		}
+		return *l, m, l.LatentHeat > 0
+	}
+	return liquid, vapor, false
+}
@@ -0,0 +1,152 @@
+package game
+
+import "math"
+
+// Point-kinetics data for a uranium-235 fuelled core, six delayed neutron
+// groups.
+var (
+	delayedFraction = [6]float64{0.000215, 0.001424, 0.001274, 0.002568, 0.000748, 0.000273}
+	delayedDecay    = [6]float64{0.0124, 0.0305, 0.111, 0.301, 1.14, 3.01} // 1/s
+)
+
+// Decay heat groups, as a share of full fission power and a decay constant
+// in 1/s. Together they give about 6% of the power just after shutdown.
+var (
+	decayHeatFraction = [3]float64{0.025, 0.020, 0.015}
+	decayHeatDecay    = [3]float64{1.0 / 10, 1.0 / 100, 1.0 / 1000}
+)
+
+const (
+	// PromptLifetime is the prompt neutron generation time in seconds.
+	PromptLifetime = 1e-4
+	// NeutronSource keeps a shut down core from reaching true zero, so it
+	// can be started up again by pulling the rods.
+	NeutronSource = 1e-6
+	// kineticsSubsteps splits each simulation step for the stiff neutron
+	// equations.
+	kineticsSubsteps = 20
+)
+
+// DelayedFraction returns the total share of neutrons that are delayed.
+func DelayedFraction() float64 {
+	beta := 0.0
+	for _, b := range delayedFraction {
+		beta += b
+	}
+	return beta
+}
+
+// Reactor is a core cooled by whatever it holds. Its neutron population
+// follows the point-kinetics equations with six groups of delayed neutrons,
+// and the fission and decay heat it makes go straight into its coolant.
+// Pipe the coolant out to a boiler to put the heat to work.
+type Reactor struct {
+	Basics
+	Structurals         // Coolant in the core
+	RatedPower  float64 // W of fission heat at a Neutrons level of 1
+	Rods        float64 // 0 fully withdrawn, 1 fully inserted
+	RodWorth    float64 // Reactivity taken away by fully inserted rods
+	Excess      float64 // Reactivity of the core with rods out at RefTemp
+	TempCoeff   float64 // Reactivity per °C of coolant above RefTemp
+	RefTemp     float64 // °C
+	Neutrons    float64 // Neutron population relative to rated power
+	Precursors  [6]float64
+	DecayHeat   [3]float64 // J stored in each decay heat group
+	Output      float64    // W of heat given to the coolant on the last step
+	Scrammed    bool       // Rods were dropped and not moved since
+}
+
+func (r *Reactor) GetStructurals() *Structurals {
+	return &r.Structurals
+}
+
+// SetRods sets the rod insertion, clamped to 0-1, and hands control back
+// after a scram.
+func (r *Reactor) SetRods(rods float64) {
+	r.Rods = math.Max(0, math.Min(1, rods))
+	r.Scrammed = false
+}
+
+// Scram drops every rod into the core.
+func (r *Reactor) Scram() {
+	r.Rods = 1
+	r.Scrammed = true
+}
+
+// Reactivity returns the reactivity of the core as it stands.
+func (r *Reactor) Reactivity() float64 {
+	return r.Excess - r.RodWorth*r.Rods + r.TempCoeff*(r.CurrentHeat-r.RefTemp)
+}
+
+// SetPower puts the core at a steady neutron level n, with its precursors
+// and decay heat settled as if it had been running there for a long time.
+func (r *Reactor) SetPower(n float64) {
+	r.Neutrons = math.Max(0, n)
+	for i := range r.Precursors {
+		r.Precursors[i] = delayedFraction[i] * r.Neutrons / (delayedDecay[i] * PromptLifetime)
+	}
+	for j := range r.DecayHeat {
+		r.DecayHeat[j] = decayHeatFraction[j] * r.Neutrons * r.RatedPower / decayHeatDecay[j]
+	}
+}
+
+// FissionPower returns the heat in W released by fission right now.
+func (r *Reactor) FissionPower() float64 {
+	prompt := 1.0
+	for _, f := range decayHeatFraction {
+		prompt -= f
+	}
+	return r.Neutrons * r.RatedPower * prompt
+}
+
+// DecayPower returns the heat in W given off by fission products.
+func (r *Reactor) DecayPower() float64 {
+	p := 0.0
+	for j, d := range r.DecayHeat {
+		p += decayHeatDecay[j] * d
+	}
+	return p
+}
+
+// Update steps the kinetics over dt and queues the heat for the coolant.
+// An overheated core scrams itself.
+func (r *Reactor) Update(s *System, dt float64) {
+	if r.Overheated {
+		r.Scram()
+	}
+	if dt <= 0 {
+		return
+	}
+
+	rho := r.Reactivity()
+	beta := DelayedFraction()
+	h := dt / kineticsSubsteps
+	heat := 0.0
+	for range kineticsSubsteps {
+		// Backward Euler, solved for the new neutron level first.
+		num, den := r.Neutrons+h*NeutronSource, 1-h*(rho-beta)/PromptLifetime
+		for i, c := range r.Precursors {
+			k := 1 + h*delayedDecay[i]
+			num += h * delayedDecay[i] * c / k
+			den -= h * h * delayedDecay[i] * delayedFraction[i] / (PromptLifetime * k)
+		}
+		n := r.Neutrons
+		if den > 0 {
+			n = num / den
+		}
+		n = math.Max(0, n)
+		for i, c := range r.Precursors {
+			r.Precursors[i] = (c + h*delayedFraction[i]*n/PromptLifetime) / (1 + h*delayedDecay[i])
+		}
+		r.Neutrons = n
+
+		for j, d := range r.DecayHeat {
+			r.DecayHeat[j] = (d + h*decayHeatFraction[j]*n*r.RatedPower) / (1 + h*decayHeatDecay[j])
+		}
+		heat += (r.FissionPower() + r.DecayPower()) * h
+	}
+
+	r.QueueHeat(heat)
+	s.account(nil, 0, heat)
+	r.Output = heat / dt
+}
@@ -0,0 +1,399 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SaveVersion is the version of the save format written by this build.
+const SaveVersion = 1
+
+// QuickSave is where F5 saves the game and F9 loads it from.
+const QuickSave = "quicksave.json"
+
+// SaveMigrations upgrade a save from the version they are keyed by to the
+// next one. They work on the decoded JSON before it is read into a
+// SaveFile, so a migration can fill in a field that Structurals gained, or
+// rename one, with ForEachState. A save older than SaveVersion is only
+// loaded if there is a migration for every version in between.
+var SaveMigrations = map[int]func(save map[string]any) error{}
+
+// SaveFile is the complete state of a game.
+type SaveFile struct {
+	Version       int
+	Width, Height int
+	Floor         []string
+	Entities      []SavedEntity
+	Clock         Clock
+	Ticks         int
+	Camera        Camera
+	Leaks         []SavedLeak  `json:",omitempty"`
+	Spills        []SavedSpill `json:",omitempty"`
+}
+
+// SavedEntity is a component made by Level.Spawn.
+type SavedEntity struct {
+	Config EntityConfig    // What it was spawned from
+	State  json.RawMessage // The component as it stands, without Refs
+	// Refs holds the components it refers to, such as a pipe's From and
+	// To, by index into SaveFile.Entities.
+	Refs map[string]int `json:",omitempty"`
+}
+
+// SavedLeak is a Leak by index into SaveFile.Entities.
+type SavedLeak struct {
+	Component int
+	Material  MaterialDef
+	Mass      float64
+}
+
+// SavedSpill is what has leaked onto the tile at X, Y.
+type SavedSpill struct {
+	X, Y  int
+	Spill Structurals
+}
+
+// Camera is where the view is and how far it is zoomed.
+type Camera struct {
+	X, Y, Scale float64
+}
+
+// ref is a field of a component that refers to another one.
+type ref struct {
+	name string
+	to   Component
+}
+
+// refs returns the components c refers to, by field name.
+func refs(c Component) []ref {
+	var r []ref
+	add := func(name string, to Component) {
+		if to != nil {
+			r = append(r, ref{name, to})
+		}
+	}
+	switch v := c.(type) {
+	case *Pipe:
+		add("From", v.From)
+		add("To", v.To)
+		if v.Valve != nil {
+			add("Valve", v.Valve)
+		}
+	case *Pump:
+		if v.Pipe != nil {
+			add("Pipe", v.Pipe)
+		}
+	case *Conveyor:
+		add("From", v.From)
+		add("To", v.To)
+	case *Feeder:
+		add("Source", v.Source)
+		add("Target", v.Target)
+	case *Furnace:
+		add("Target", v.Target)
+	}
+	return r
+}
+
+// setRef points the field name of c at to, or clears it if to is nil.
+func setRef(c Component, name string, to Component) error {
+	switch v := c.(type) {
+	case *Pipe:
+		switch name {
+		case "From":
+			v.From = to
+			return nil
+		case "To":
+			v.To = to
+			return nil
+		case "Valve":
+			if valve, ok := to.(*Valve); ok || to == nil {
+				v.Valve = valve
+				return nil
+			}
+			return fmt.Errorf("Valve refers to a %T", to)
+		}
+	case *Pump:
+		if name == "Pipe" {
+			if p, ok := to.(*Pipe); ok || to == nil {
+				v.Pipe = p
+				return nil
+			}
+			return fmt.Errorf("Pipe refers to a %T", to)
+		}
+	case *Conveyor:
+		switch name {
+		case "From":
+			v.From = to
+			return nil
+		case "To":
+			v.To = to
+			return nil
+		}
+	case *Feeder:
+		switch name {
+		case "Source":
+			v.Source = to
+			return nil
+		case "Target":
+			v.Target = to
+			return nil
+		}
+	case *Furnace:
+		if name == "Target" {
+			v.Target = to
+			return nil
+		}
+	}
+	return fmt.Errorf("%T has no reference %q", c, name)
+}
+
+// Snapshot records the state of everything Spawn made in l, along with the
+// clock, leaks and spills. Entities added without Spawn are not included.
+func (l *Level) Snapshot() (*SaveFile, error) {
+	f := &SaveFile{
+		Version: SaveVersion,
+		Width:   l.Width,
+		Height:  l.Height,
+		Floor:   append([]string(nil), l.floor...),
+	}
+	index := make(map[Component]int, len(l.spawns))
+	for i, sp := range l.spawns {
+		index[sp.component] = i
+	}
+
+	var errs []error
+	for _, sp := range l.spawns {
+		state, err := json.Marshal(sp.component)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", Identifier(sp.component), err))
+			continue
+		}
+		e := SavedEntity{Config: sp.config, State: state}
+		for _, r := range refs(sp.component) {
+			i, ok := index[r.to]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: %s refers to %s, which was not spawned", Identifier(sp.component), r.name, Identifier(r.to)))
+				continue
+			}
+			if e.Refs == nil {
+				e.Refs = make(map[string]int)
+			}
+			e.Refs[r.name] = i
+		}
+		f.Entities = append(f.Entities, e)
+	}
+
+	if s := l.System; s != nil {
+		f.Clock = s.Clock
+		f.Ticks = s.Ticks
+		for _, leak := range s.Leaks {
+			if i, ok := index[leak.Component]; ok {
+				f.Leaks = append(f.Leaks, SavedLeak{Component: i, Material: leak.Material, Mass: leak.Mass})
+			}
+		}
+	}
+	for y, row := range l.tiles {
+		for x, t := range row {
+			if t.Spill.Quantity > 0 {
+				f.Spills = append(f.Spills, SavedSpill{X: x, Y: y, Spill: t.Spill})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return f, nil
+}
+
+// Restore builds a level, and a new System to go with it, from a save.
+func Restore(f *SaveFile) (*Level, error) {
+	floor := f.Floor
+	if len(floor) > 0 && len(floor) != f.Height {
+		return nil, fmt.Errorf("floor has %d rows, want %d", len(floor), f.Height)
+	}
+	if f.Width <= 0 || f.Height <= 0 {
+		return nil, fmt.Errorf("map size %dx%d is not positive", f.Width, f.Height)
+	}
+	sys := &System{}
+	l, err := newEmptyLevel(f.Width, f.Height, sys, func(x, y int) bool {
+		return len(floor) == 0 || x < len(floor[y]) && floor[y][x] == '#'
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	comps := make([]Component, len(f.Entities))
+	for i, e := range f.Entities {
+		// Refs say what it is connected to now, which may no longer be
+		// what the config named.
+		config := e.Config
+		config.From, config.To = "", ""
+		ent, err := l.Spawn(config)
+		if err == nil {
+			comps[i] = ent.Component
+			err = json.Unmarshal(e.State, comps[i])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entity %d (%q): %w", i, e.Config.Identifier, err))
+		}
+	}
+	for i, e := range f.Entities {
+		if comps[i] == nil {
+			continue
+		}
+		for name, j := range e.Refs {
+			if j < 0 || j >= len(comps) || comps[j] == nil {
+				errs = append(errs, fmt.Errorf("entity %d: %s refers to missing entity %d", i, name, j))
+				continue
+			}
+			if err := setRef(comps[i], name, comps[j]); err != nil {
+				errs = append(errs, fmt.Errorf("entity %d: %w", i, err))
+			}
+		}
+	}
+
+	sys.Clock = f.Clock
+	sys.Ticks = f.Ticks
+	for _, leak := range f.Leaks {
+		if leak.Component < 0 || leak.Component >= len(comps) || comps[leak.Component] == nil {
+			errs = append(errs, fmt.Errorf("leak from missing entity %d", leak.Component))
+			continue
+		}
+		sys.Leaks = append(sys.Leaks, Leak{Component: comps[leak.Component], Material: leak.Material, Mass: leak.Mass})
+	}
+	for _, sp := range f.Spills {
+		t := l.Tile(sp.X, sp.Y)
+		if t == nil {
+			errs = append(errs, fmt.Errorf("spill on tile %d,%d outside the map", sp.X, sp.Y))
+			continue
+		}
+		t.Spill = sp.Spill
+	}
+	l.building = false
+	l.Revalidate()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return l, nil
+}
+
+// WriteSave writes f to w as JSON.
+func WriteSave(w io.Writer, f *SaveFile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// ReadSave reads a save from r, running SaveMigrations on it first if it
+// was written by an older version.
+func ReadSave(r io.Reader) (*SaveFile, error) {
+	var raw map[string]any
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, ok := raw["Version"].(float64); ok {
+		version = int(v)
+	}
+	if version > SaveVersion {
+		return nil, fmt.Errorf("save version %d is newer than %d", version, SaveVersion)
+	}
+	for ; version < SaveVersion; version++ {
+		migrate, ok := SaveMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from save version %d", version)
+		}
+		if err := migrate(raw); err != nil {
+			return nil, fmt.Errorf("migrating save version %d: %w", version, err)
+		}
+		raw["Version"] = version + 1
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var f SaveFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ForEachState calls fn with the state of every entity in a decoded save,
+// for use by SaveMigrations. Changes fn makes to state are kept.
+func ForEachState(save map[string]any, fn func(state map[string]any) error) error {
+	entities, _ := save["Entities"].([]any)
+	for i, e := range entities {
+		entity, ok := e.(map[string]any)
+		if !ok {
+			return fmt.Errorf("entity %d is not an object", i)
+		}
+		state, ok := entity["State"].(map[string]any)
+		if !ok {
+			return fmt.Errorf("entity %d has no state", i)
+		}
+		if err := fn(state); err != nil {
+			return fmt.Errorf("entity %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Save writes the game, including the camera, to w.
+func (g *Game) Save(w io.Writer) error {
+	f, err := g.currentLevel.Snapshot()
+	if err != nil {
+		return err
+	}
+	f.Camera = Camera{X: g.camX, Y: g.camY, Scale: g.camScaleTo}
+	return WriteSave(w, f)
+}
+
+// Load replaces the game with the one saved in r.
+func (g *Game) Load(r io.Reader) error {
+	f, err := ReadSave(r)
+	if err != nil {
+		return err
+	}
+	l, err := Restore(f)
+	if err != nil {
+		return err
+	}
+	g.SetLevel(l)
+	g.camX, g.camY = f.Camera.X, f.Camera.Y
+	if f.Camera.Scale > 0 {
+		g.camScale, g.camScaleTo = f.Camera.Scale, f.Camera.Scale
+	}
+	return nil
+}
+
+// SaveTo saves the game to the file at path.
+func (g *Game) SaveTo(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := g.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadFrom loads the game saved in the file at path.
+func (g *Game) LoadFrom(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return g.Load(file)
+}
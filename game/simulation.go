@@ -1,17 +1,18 @@
 package game
 
-import (
-	"log"
-	"math"
-)
+import "math"
 
 const (
 	Gravity      = 9.81
-	TimeStep     = 1.0 / 6.0
+	TimeStep     = 1.0 / 6.0 // Default simulated seconds per step
 	FrictionFact = 0.02
 	MinorLoss    = 1.5
+	// DefaultTurbineLoad takes two thirds of the head, which gives the most
+	// power through a pipe whose remaining head goes to friction.
+	DefaultTurbineLoad = 2.0 / 3.0
 )
 
+// GetMaterial returns the dominant material held by c.
 func GetMaterial(c Component) *MaterialDef {
 	s := c.GetStructurals()
 	if s == nil || len(s.Contents) == 0 {
@@ -20,6 +21,8 @@ func GetMaterial(c Component) *MaterialDef {
 	return &s.Contents[0]
 }
 
+// TotalHead returns the head of c in meters of water, built up from every
+// material it holds.
 func TotalHead(c Component) float64 {
 	if c == nil {
 		return 0
@@ -29,70 +32,144 @@ func TotalHead(c Component) float64 {
 		return 0
 	}
 
-	mat := GetMaterial(c)
+	contents, amounts := s.portions()
+	liquidVol := 0.0
+	for i, mat := range contents {
+		if mat.Type == TypeFluid && mat.Density > 0 { // prevent div/0
+			liquidVol += amounts[i] / mat.Density
+		}
+	}
+
+	head := s.BaseElevation
 
 	// Case 1: Fluid (Hydrostatic)
-	if mat.Type == TypeFluid {
-		if s.Area == 0 {
-			return s.BaseElevation
-		}
-		// Volume = Mass / Density
-		// Head = Volume / Area = (Mass / Density) / Area
-		if mat.Density == 0 {
-			return s.BaseElevation
-		} // prevent div/0
-		return (s.Quantity / (mat.Density * s.Area)) + s.BaseElevation
+	// Volume = Mass / Density
+	// Head = Volume / Area
+	if s.Area > 0 {
+		head += liquidVol / s.Area
 	}
 
-	// Case 2: Gas (Compressible)
+	// Case 2: Gas (Compressible), filling the space above the liquid and
+	// pressing on it. Heads are gauge, so only pressure above atmospheric
+	// counts.
+	// P = (Mass * R * T) / Vol_free
 	// Head = P / (rho_water * g)
-	// P = (Mass * R) / Vol_container
-	if mat.Type == TypeGas {
-		if mat.GasConstant > 0 {
-			if s.MaxVolume == 0 {
-				return 0
-			}
-			pressure := (s.Quantity * mat.GasConstant) / s.MaxVolume
-			// Convert pressure to head (meters of water)
-			// P = rho * g * h => h = P / (rho * g)
-			// Using Water density as reference for Head
-			return pressure / (Water.Density * Gravity)
-		}
+	if p := GasPressure(c); p > AtmosphericPressure {
+		head += (p - AtmosphericPressure) / (Water.Density * Gravity)
 	}
 
-	return s.BaseElevation
+	return head
 }
 
+// ApplyPending commits the queued change of c. Per-material changes from
+// PendingAmounts are applied as-is; a bare PendingChange with no
+// PendingAmounts is credited to the dominant material. No material is
+// allowed to go below zero. PendingHeat is folded into the temperature of
+// whatever is left.
 func ApplyPending(c Component) {
+	applyPending(c)
+}
+
+// applyPending is ApplyPending, returning the heat that was dropped because
+// c was left empty.
+func applyPending(c Component) (dropped float64) {
 	if c == nil {
-		return
+		return 0
 	}
 	r := c.GetStructurals()
 	if r == nil {
-		return
+		return 0
+	}
+	if tracing(c) {
+		trace(c, "apply pending", "quantity", r.Quantity, "change", r.PendingChange)
+	}
+	energy := HeatCapacity(r)*r.CurrentHeat + r.PendingHeat
+	if len(r.PendingAmounts) > 0 {
+		r.syncAmounts()
+		for i, d := range r.PendingAmounts {
+			r.Amounts[i] += d
+		}
+	} else if r.PendingChange != 0 {
+		if len(r.Contents) == 0 {
+			r.Contents = []MaterialDef{Water}
+			r.Amounts = nil
+		}
+		r.syncAmounts()
+		if len(r.Amounts) > 0 {
+			r.Amounts[0] += r.PendingChange
+		}
+	}
+	for i := range r.Amounts {
+		if r.Amounts[i] < 0 {
+			r.Amounts[i] = 0
+		}
 	}
-	log.Printf("ApplyPending %v quantity=%.2f change=%.3f", Identifier(c), r.Quantity, r.PendingChange)
-	r.Quantity += r.PendingChange
 	r.PendingChange = 0
-	if r.Quantity < 0 {
-		r.Quantity = 0
+	r.PendingAmounts = nil
+	r.settle()
+
+	// An empty component keeps its last temperature.
+	if capacity := HeatCapacity(r); capacity > 0 {
+		r.CurrentHeat = energy / capacity
+	} else {
+		dropped = energy
 	}
+	r.PendingHeat = 0
+	checkHeat(r)
+	return dropped
 }
 
 type System struct {
 	Nodes []Component
 	Pipes []*Pipe
-	Ticks int
+	// Machines act every step without being part of the pipe graph, such
+	// as pumps driving a pipe.
+	Machines []Component
+	Ticks    int // Frames the simulation has been advanced while running
+	Clock    Clock
+	Grid     Grid
+	Leaks    []Leak   // Material lost by damaged components, see TakeLeaks
+	Audit    *Auditor // Checks every step for conservation when set
 }
 
+// Tick advances the simulation by one frame at TicksPerSecond.
 func (s *System) Tick() {
-	s.Ticks++
-	if s.Ticks%10 != 0 {
+	s.Advance(1.0 / TicksPerSecond)
+}
+
+// Advance feeds realDt seconds of wall time to the clock and runs every step
+// that falls due. Nothing happens while the clock is paused.
+func (s *System) Advance(realDt float64) {
+	if s.Clock.Paused {
 		return
 	}
+	s.Ticks++
+	for range s.Clock.Advance(realDt) {
+		s.Step()
+	}
+}
+
+// StepN runs n simulation steps immediately, whether or not the clock is
+// paused.
+func (s *System) StepN(n int) {
+	for range n {
+		s.Step()
+	}
+}
+
+// Step runs a single simulation step of Clock.StepSize seconds.
+func (s *System) Step() {
+	dt := s.Clock.StepSize()
+	comps := s.Network()
+	if s.Audit != nil {
+		s.Audit.begin(s)
+	}
 
-	log.Printf("SIM Ticks=%d Nodes=%d Pipes=%d", s.Ticks, len(s.Nodes), len(s.Pipes))
+	Logger.Debug("step", "steps", s.Clock.Steps, "nodes", len(s.Nodes), "pipes", len(s.Pipes))
 	for i, p := range s.Pipes {
+		if p == nil || !tracing(p) {
+			continue
+		}
 		in := p.From
 		out := p.To
 		var inS, outS *Structurals
@@ -102,68 +179,89 @@ func (s *System) Tick() {
 		if out != nil {
 			outS = out.GetStructurals()
 		}
-		log.Printf("Pipe[%d] area=%.3f len=%.3f from=%v quantity=%.2f pres=%.3f -> to=%v quantity=%.2f pres=%.3f",
-			i, p.Area, p.Length,
-			Identifier(in), inS.Quantity, Pres(inS),
-			Identifier(out), outS.Quantity, Pres(outS))
+		trace(p, "pipe", "index", i, "area", p.Area, "len", p.Length,
+			"from", Identifier(in), "from_quantity", Qty(inS), "from_pres", Pres(inS),
+			"to", Identifier(out), "to_quantity", Qty(outS), "to_pres", Pres(outS))
 	}
 
-	for _, p := range s.Pipes {
-		// Calculate Flow 1: Source -> Pipe
-		if p.From != nil {
-			CalculateFlow(p.From, p, p.PumpHead) // PumpHead usually applies to flow *through* pipe?
-			// Let's assume PumpHead helps move From -> To.
-			// For simplicity: From -> Pipe (Gravity/Pressure), Pipe -> To (Gravity/Pressure + Pump?)
-			// Or apply PumpHead to the whole path?
-			// Let's stick to simple Head diff for Input.
-		}
-
-		// Calculate Flow 2: Pipe -> Destination
-		if p.To != nil {
-			CalculateFlow(p, p.To, p.PumpHead)
+	for _, c := range append(comps, s.Machines...) {
+		if u, ok := c.(Updater); ok {
+			u.Update(s, dt)
 		}
 	}
 
-	// Update all Nodes and Pipes
-	for _, node := range s.Nodes {
-		ApplyPending(node)
+	s.Solve(dt)
+	s.conduct(dt)
+	s.loseHeat(dt)
+
+	// Update every component in the network, including pipe ends that were
+	// never registered as nodes, so no queued change is left behind.
+	for _, c := range comps {
+		s.account(nil, 0, -applyPending(c))
+		changePhase(c)
+		s.checkPressure(c, dt)
 	}
-	for _, pipe := range s.Pipes {
-		ApplyPending(pipe)
+
+	s.Grid.Balance()
+	if s.Audit != nil {
+		s.Audit.end(s)
 	}
+
+	s.Clock.Time += dt
+	s.Clock.Steps++
 }
 
+// CalculateFlow queues the flow across a single connection, limited by what
+// the source holds and what the destination can store. Flow runs to -> from
+// when to has the higher head. System.Tick uses System.Solve instead, which
+// balances every connection together.
 func CalculateFlow(from, to Component, pumpHead float64) {
 	if from == nil || to == nil {
 		return
 	}
 
-	pFrom := from.GetStructurals()
-	pTo := to.GetStructurals()
+	deltaH := (TotalHead(from) + pumpHead) - TotalHead(to)
+	if deltaH < 0 {
+		from, to, deltaH = to, from, -deltaH
+	}
+
+	amountMoving := desiredFlow(from, to, deltaH, TimeStep)
+	if amountMoving <= 0 {
+		return
+	}
+
+	// Constraint: Source Quantity
+	if q := from.GetStructurals().fluidMass(); amountMoving > q {
+		amountMoving = q
+	}
 
-	headFrom := TotalHead(from)
-	headTo := TotalHead(to)
+	// Constraint: Dest Capacity
+	if space := freeMass(to, from); amountMoving > space {
+		amountMoving = space
+	}
 
-	deltaH := (headFrom + pumpHead) - headTo
+	src := from.GetStructurals()
+	moveMass(from, to, amountMoving, src.Contents, src.drawFractions(amountMoving))
+}
 
+// desiredFlow returns the mass that a head difference of deltaH would push
+// from -> to in dt seconds, before any source or capacity limits.
+func desiredFlow(from, to Component, deltaH, dt float64) float64 {
 	if deltaH < 0.00001 {
-		return
+		return 0
 	}
 
 	// Simple flow calc
-	// Assuming generic connection properties?
-	// If one is a Pipe, use its geometry?
-	// We need 'Connection Geometry'.
 	// For Source->Pipe, use Pipe geometry.
 	// For Pipe->Dest, use Pipe geometry.
-
+	// A valve on the pipe narrows its area.
 	var area, length, radius float64
 	if pipe, ok := to.(*Pipe); ok {
-		area = pipe.Area
+		area = pipe.Area * pipe.OpenFraction()
 		length = pipe.Length / 2 // Half length for input?
 		radius = pipe.Radius
 	} else if pipe, ok := from.(*Pipe); ok {
-		area = pipe.Area
+		area = pipe.Area * pipe.OpenFraction()
 		length = pipe.Length / 2 // Half length for output?
 		radius = pipe.Radius
 	} else {
@@ -174,41 +272,22 @@ func CalculateFlow(from, to Component, pumpHead float64) {
 	}
 
 	// Bernoulli
-	frictionLoss := FrictionFact * (length / (2 * radius))
-	velocity := math.Sqrt((2 * Gravity * deltaH) / (1 + frictionLoss + MinorLoss))
-
-	flowVol := velocity * area * TimeStep
-
-	// Check content/density
-	mat := GetMaterial(from)
-	density := mat.Density
-	if density == 0 {
-		density = 1000
-	}
-
-	amountMoving := flowVol * density
-
-	// Constraint: Source Quantity
-	if amountMoving > pFrom.Quantity {
-		amountMoving = pFrom.Quantity
-	}
-
-	// Constraint: Dest Capacity
-	// If Dest is Pipe or Reservoir, check MaxVolume
-	currentVol := pTo.Quantity / density
-	spaceVol := pTo.MaxVolume - currentVol
-
-	if spaceVol <= 0 {
-		return // Full
+	// Heads are in meters of water, so a light gas is pushed far faster
+	// than water by the same head.
+	density, gas := outflow(from)
+	drive := deltaH
+	if gas {
+		drive *= Water.Density / density
 	}
+	frictionLoss := FrictionFact * (length / (2 * radius))
+	velocity := math.Sqrt((2 * Gravity * drive) / (1 + frictionLoss + MinorLoss))
 
-	volMoving := amountMoving / density
-	if volMoving > spaceVol {
-		amountMoving = spaceVol * density
+	massFlow := velocity * area * density
+	if gas {
+		massFlow = math.Min(massFlow, chokedFlow(from, area))
 	}
 
-	pFrom.PendingChange -= amountMoving
-	pTo.PendingChange += amountMoving
+	return massFlow * dt
 }
 
 // func buildChainSystem(n int) *System {
@@ -0,0 +1,155 @@
+package game
+
+import "math"
+
+// Bulk solids such as Coal never flow through pipes. They sit in hoppers,
+// ride conveyors from tile to tile and are metered out by feeders, all of
+// which move them straight from one component to another.
+
+// moveSolid moves up to mass of m from -> to at once and returns what was
+// moved. It is limited by what from holds and the room left in to. The
+// solid carries its heat with it at from's temperature.
+func moveSolid(from, to Component, m MaterialDef, mass float64) float64 {
+	if from == nil || to == nil || mass <= 0 {
+		return 0
+	}
+	src, dst := from.GetStructurals(), to.GetStructurals()
+	mass = math.Min(mass, src.AmountOf(m))
+	mass = math.Min(mass, solidRoom(dst, m))
+	if mass <= 0 {
+		return 0
+	}
+
+	capacity := HeatCapacity(dst)
+	energy := capacity*dst.CurrentHeat + mass*m.SpecificHeat*src.CurrentHeat
+	src.AddMaterial(m, -mass)
+	dst.AddMaterial(m, mass)
+	if after := capacity + mass*m.SpecificHeat; after > 0 {
+		dst.CurrentHeat = energy / after
+	}
+	return mass
+}
+
+// solidRoom returns how much more of the solid m s can store. Components
+// with no MaxVolume take any amount.
+func solidRoom(s *Structurals, m MaterialDef) float64 {
+	if s.MaxVolume <= 0 {
+		return math.Inf(1)
+	}
+	density := m.Density
+	if density <= 0 {
+		density = Water.Density
+	}
+	return math.Max(0, (s.MaxVolume-s.Volume())*density)
+}
+
+// firstSolid returns the first solid held by c, if any.
+func firstSolid(c Component) (MaterialDef, bool) {
+	contents, amounts := c.GetStructurals().portions()
+	for i, m := range contents {
+		if m.Type == TypeSolid && amounts[i] > 0 {
+			return m, true
+		}
+	}
+	return MaterialDef{}, false
+}
+
+// Hopper is a bunker that stores bulk solids.
+type Hopper struct {
+	Basics
+	Structurals
+}
+
+func (h *Hopper) GetStructurals() *Structurals {
+	return &h.Structurals
+}
+
+// BeltLoad is a batch of material riding a conveyor, Pos tiles from its
+// start.
+type BeltLoad struct {
+	Material MaterialDef
+	Mass     float64
+	Pos      float64
+}
+
+// Conveyor is a belt laid across Length tiles that carries solids from From
+// to To. It picks up at most Throughput kg/s and each batch takes
+// Length/Speed seconds to reach the end. When To has no room for the batch
+// at the end, the whole belt stops.
+type Conveyor struct {
+	Basics
+	Structurals           // What is on the belt
+	From, To    Component `json:"-"`
+	Length      float64   // Tiles
+	Speed       float64   // Tiles/s
+	Throughput  float64   // kg/s
+	Loads       []BeltLoad
+	Stalled     bool // To had no room on the last step
+}
+
+func (c *Conveyor) GetStructurals() *Structurals {
+	return &c.Structurals
+}
+
+// Update moves the belt along, unloads what reached the end and picks up
+// the next batch from From.
+func (c *Conveyor) Update(s *System, dt float64) {
+	c.Stalled = false
+	if c.Speed <= 0 {
+		return
+	}
+	step := c.Speed * dt
+
+	// Batches leave from the front of the belt.
+	for len(c.Loads) > 0 && c.Loads[0].Pos+step >= c.Length {
+		l := &c.Loads[0]
+		l.Mass -= moveSolid(c, c.To, l.Material, l.Mass)
+		if l.Mass > minLeakMass {
+			c.Stalled = true
+			return
+		}
+		c.Loads = c.Loads[1:]
+	}
+	for i := range c.Loads {
+		c.Loads[i].Pos += step
+	}
+
+	if c.From == nil {
+		return
+	}
+	if m, ok := firstSolid(c.From); ok {
+		if mass := moveSolid(c.From, c, m, c.Throughput*dt); mass > 0 {
+			c.Loads = append(c.Loads, BeltLoad{Material: m, Mass: mass})
+		}
+	}
+}
+
+// Feeder meters solids from Source into Target at Rate kg/s while it is on.
+type Feeder struct {
+	Basics
+	Structurals
+	Source, Target Component `json:"-"`
+	Rate           float64   // kg/s
+	On             bool
+	Fed            float64 // kg/s delivered on the last step
+}
+
+func (f *Feeder) GetStructurals() *Structurals {
+	return &f.Structurals
+}
+
+// SetRate sets the feed rate, never below zero.
+func (f *Feeder) SetRate(rate float64) {
+	f.Rate = math.Max(0, rate)
+}
+
+// Update feeds the first solid Source holds into Target.
+func (f *Feeder) Update(s *System, dt float64) {
+	f.Fed = 0
+	if !f.On || f.Source == nil || f.Target == nil || dt <= 0 {
+		return
+	}
+	if m, ok := firstSolid(f.Source); ok {
+		f.Fed = moveSolid(f.Source, f.Target, m, f.Rate*dt) / dt
+	}
+}
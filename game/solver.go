@@ -0,0 +1,374 @@
+package game
+
+import "math"
+
+// flow is a single directed transfer of mass between two connected components.
+// head is what a turbine at the source took from it, in meters.
+type flow struct {
+	from, to Component
+	amount   float64
+	head     float64
+}
+
+// Network returns every component that takes part in the pipe graph, without
+// duplicates. Registered nodes come first, then pipe ends that were never
+// registered, then the pipes themselves, so the order is stable between ticks.
+func (s *System) Network() []Component {
+	seen := make(map[Component]bool)
+	comps := make([]Component, 0, len(s.Nodes)+len(s.Pipes))
+	add := func(c Component) {
+		if c == nil || seen[c] {
+			return
+		}
+		seen[c] = true
+		comps = append(comps, c)
+	}
+
+	for _, n := range s.Nodes {
+		add(n)
+	}
+	for _, p := range s.Pipes {
+		if p != nil {
+			add(p.From)
+			add(p.To)
+		}
+	}
+	for _, p := range s.Pipes {
+		if p != nil {
+			add(p)
+		}
+	}
+	return comps
+}
+
+// Solve computes the flow on every connection of the network over dt seconds
+// at once and queues the result as PendingChange on each component.
+//
+// Heads are sampled for all components before any mass moves, so the result
+// does not depend on the order of System.Nodes or System.Pipes. When a
+// component is asked to give more than it holds, each of its outflows is
+// scaled down proportionally; when a component is offered more than it can
+// store, each of its inflows is scaled down the same way. Every unit of mass
+// taken from one component is handed to another, so the total is conserved.
+// Only liquids and gases move; solids stay where they are.
+//
+// Junctions (Structurals.IsJunction) store nothing. Their head is whatever
+// makes their inflow match their outflow, and fluid passes straight through
+// them within the step, leaving as the mix of what came in.
+func (s *System) Solve(dt float64) {
+	comps := s.Network()
+
+	heads := make(map[Component]float64, len(comps))
+	for _, c := range comps {
+		heads[c] = TotalHead(c)
+	}
+	isJunction := func(c Component) bool {
+		return c.GetStructurals().IsJunction
+	}
+	for _, c := range comps {
+		if isJunction(c) {
+			heads[c] = s.junctionHead(c, heads, dt)
+		}
+	}
+
+	flows := make([]flow, 0, 2*len(s.Pipes))
+	connect := func(from, to Component, pumpHead float64, checkValve bool) {
+		if from == nil || to == nil {
+			return
+		}
+		// Fluid runs down the head gradient in whichever direction it points,
+		// unless a check valve holds it to From -> To.
+		deltaH := (heads[from] + pumpHead) - heads[to]
+		if deltaH < 0 {
+			if checkValve {
+				return
+			}
+			from, to, deltaH = to, from, -deltaH
+		}
+		// A turbine keeps part of the head for itself, leaving less to
+		// drive the flow.
+		taken := 0.0
+		if t, ok := from.(Turbine); ok {
+			taken = deltaH * t.HeadFraction()
+		}
+		if amount := desiredFlow(from, to, deltaH-taken, dt); amount > 0 {
+			flows = append(flows, flow{from: from, to: to, amount: amount, head: taken})
+		}
+	}
+	for _, p := range s.Pipes {
+		if p == nil {
+			continue
+		}
+		connect(p.From, p, p.endHead(), p.CheckValve)
+		connect(p, p.To, p.endHead(), p.CheckValve)
+	}
+
+	// Over-subscribed sources share what they hold between their outflows.
+	outflow := make(map[Component]float64)
+	for _, f := range flows {
+		outflow[f.from] += f.amount
+	}
+	for i, f := range flows {
+		if isJunction(f.from) {
+			continue
+		}
+		avail := f.from.GetStructurals().fluidMass()
+		if total := outflow[f.from]; total > avail {
+			if total > 0 && avail > 0 {
+				flows[i].amount *= avail / total
+			} else {
+				flows[i].amount = 0
+			}
+		}
+	}
+
+	// Over-subscribed destinations share their free space between their inflows.
+	inflow := make(map[Component]float64)
+	for _, f := range flows {
+		inflow[f.to] += f.amount
+	}
+	for i, f := range flows {
+		if isJunction(f.to) {
+			continue
+		}
+		space := freeMass(f.to, f.from)
+		if total := inflow[f.to]; total > space {
+			if total > 0 && space > 0 {
+				flows[i].amount *= space / total
+			} else {
+				flows[i].amount = 0
+			}
+		}
+	}
+
+	// Limits on either side may have cut a junction's flows unevenly, so
+	// trim the larger side until it passes on exactly what it takes in.
+	passIn := make(map[Component]float64)
+	passOut := make(map[Component]float64)
+	for _, f := range flows {
+		if isJunction(f.to) {
+			passIn[f.to] += f.amount
+		}
+		if isJunction(f.from) {
+			passOut[f.from] += f.amount
+		}
+	}
+	for i, f := range flows {
+		if isJunction(f.to) && passIn[f.to] > passOut[f.to] {
+			flows[i].amount *= passOut[f.to] / passIn[f.to]
+		}
+		if isJunction(f.from) && passOut[f.from] > passIn[f.from] {
+			flows[i].amount *= passIn[f.from] / passOut[f.from]
+		}
+	}
+
+	// Every outflow of a source carries the same mix, drawn from what the
+	// source held at the start of the step.
+	drawn := make(map[Component]float64)
+	for _, f := range flows {
+		drawn[f.from] += f.amount
+	}
+	contents := make(map[Component][]MaterialDef, len(drawn))
+	fractions := make(map[Component][]float64, len(drawn))
+	for c, total := range drawn {
+		if isJunction(c) {
+			continue
+		}
+		s := c.GetStructurals()
+		fractions[c] = s.drawFractions(total)
+		contents[c] = append([]MaterialDef(nil), s.Contents...)
+	}
+	// A junction passes on the mix, and the heat, of what flows into it.
+	for c, total := range drawn {
+		if isJunction(c) {
+			contents[c], fractions[c] = junctionMix(c, flows, contents, fractions, total)
+		}
+	}
+
+	for _, p := range s.Pipes {
+		if p != nil {
+			p.Flow = 0
+		}
+	}
+	for _, f := range flows {
+		if p, ok := f.from.(*Pipe); ok && f.to == p.To {
+			p.Flow += f.amount / dt
+		}
+		if p, ok := f.to.(*Pipe); ok && f.from == p.To {
+			p.Flow -= f.amount / dt
+		}
+		moveMass(f.from, f.to, f.amount, contents[f.from], fractions[f.from])
+		if t, ok := f.from.(Turbine); ok && f.head > 0 {
+			s.extract(t, f.amount, f.head, dt)
+		}
+	}
+}
+
+// junctionHead returns the head at junction j that makes the mass flowing
+// into it over dt equal the mass flowing out, given the heads of the pipes
+// around it.
+func (s *System) junctionHead(j Component, heads map[Component]float64, dt float64) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range s.Pipes {
+		if p == nil || (p.From != j && p.To != j) {
+			continue
+		}
+		pump := math.Abs(p.endHead())
+		lo = math.Min(lo, heads[p]-pump)
+		hi = math.Max(hi, heads[p]+pump)
+	}
+	if lo > hi {
+		return heads[j] // Not connected to anything
+	}
+
+	// net returns the mass that would flow into j at head h.
+	net := func(h float64) float64 {
+		in := 0.0
+		for _, p := range s.Pipes {
+			if p == nil {
+				continue
+			}
+			if p.From == j {
+				in -= endFlow(j, h, p, heads[p], p.endHead(), p.CheckValve, dt)
+			}
+			if p.To == j {
+				in += endFlow(p, heads[p], j, h, p.endHead(), p.CheckValve, dt)
+			}
+		}
+		return in
+	}
+	for range 60 {
+		mid := (lo + hi) / 2
+		if net(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// pumpedFlow returns the volume in m^3/s that a pump head h would drive
+// through p over dt from the heads of its ends now, split between the ends
+// as Solve does. What gets through is the lesser of what enters and what
+// leaves, the rest only fills the pipe.
+func pumpedFlow(p *Pipe, h, dt float64) float64 {
+	density := MixDensity(p)
+	if p.From == nil || p.To == nil || dt <= 0 || density <= 0 {
+		return 0
+	}
+	hp := TotalHead(p)
+	in := endFlow(p.From, TotalHead(p.From), p, hp, h/2, p.CheckValve, dt)
+	out := endFlow(p, hp, p.To, TotalHead(p.To), h/2, p.CheckValve, dt)
+	return math.Min(in, out) / dt / density
+}
+
+// endFlow returns the mass a head difference pushes from -> to along one end
+// of a pipe over dt, negative when it runs to -> from.
+func endFlow(from Component, hFrom float64, to Component, hTo, pumpHead float64, checkValve bool, dt float64) float64 {
+	deltaH := hFrom + pumpHead - hTo
+	if deltaH >= 0 {
+		return desiredFlow(from, to, deltaH, dt)
+	}
+	if checkValve {
+		return 0
+	}
+	return -desiredFlow(to, from, -deltaH, dt)
+}
+
+// junctionMix returns the materials and shares junction j passes on when
+// total mass leaves it, made up of everything flowing into it. The
+// junction takes on the temperature of the mix so the heat it passes on is
+// the heat it received.
+func junctionMix(j Component, flows []flow, contents map[Component][]MaterialDef, fractions map[Component][]float64, total float64) ([]MaterialDef, []float64) {
+	var mix []MaterialDef
+	var mass []float64
+	heat, capacity := 0.0, 0.0
+	for _, f := range flows {
+		if f.to != j || f.amount <= 0 {
+			continue
+		}
+		src := f.from.GetStructurals()
+		for i, m := range contents[f.from] {
+			part := f.amount * fractions[f.from][i]
+			if part <= 0 {
+				continue
+			}
+			n := len(mix)
+			for k := range mix {
+				if sameMaterial(mix[k], m) {
+					n = k
+					break
+				}
+			}
+			if n == len(mix) {
+				mix = append(mix, m)
+				mass = append(mass, 0)
+			}
+			mass[n] += part
+			heat += part * m.SpecificHeat * src.CurrentHeat
+			capacity += part * m.SpecificHeat
+		}
+	}
+	if capacity > 0 {
+		j.GetStructurals().CurrentHeat = heat / capacity
+	}
+	shares := make([]float64, len(mass))
+	if total > 0 {
+		for i := range mass {
+			shares[i] = mass[i] / total
+		}
+	}
+	return mix, shares
+}
+
+// extract hands a turbine the work of mass dropping through head meters of
+// water. Work done by a gas comes out of its heat.
+func (s *System) extract(t Turbine, mass, head, dt float64) {
+	// W = V * dP, with dP = rho_water * g * head
+	volume := mass / MixDensity(t)
+	work := volume * Water.Density * Gravity * head
+	if GetMaterial(t).Type == TypeGas {
+		t.GetStructurals().QueueHeat(-work)
+		s.account(nil, 0, -work)
+	}
+	t.Extract(work, volume, dt)
+}
+
+// moveMass queues amount of mass from -> to, split between contents by
+// fractions. The mass carries its heat with it at the source's temperature.
+func moveMass(from, to Component, amount float64, contents []MaterialDef, fractions []float64) {
+	if amount <= 0 {
+		return
+	}
+	src, dst := from.GetStructurals(), to.GetStructurals()
+	for i, m := range contents {
+		mass := amount * fractions[i]
+		if mass == 0 {
+			continue
+		}
+		src.QueueMaterial(m, -mass)
+		dst.QueueMaterial(m, mass)
+
+		heat := mass * m.SpecificHeat * src.CurrentHeat
+		src.QueueHeat(-heat)
+		dst.QueueHeat(heat)
+	}
+}
+
+// freeMass returns how much more of what leaves from c can store. Liquids
+// fill the space left free. Gases are compressible, so c takes gas until it
+// holds it as densely as from does.
+func freeMass(c, from Component) float64 {
+	s := c.GetStructurals()
+	space := s.MaxVolume - s.Volume()
+	if space <= 0 {
+		return 0
+	}
+	density, gas := outflow(from)
+	if gas {
+		held, _, _ := s.gas()
+		return math.Max(0, space*density-held)
+	}
+	return space * density
+}
@@ -26,7 +26,9 @@ type SpriteSheet struct {
 	PipeRightToDown *ebiten.Image
 }
 
-// LoadSpriteSheet loads the embedded SpriteSheet.
+// LoadSpriteSheet loads the embedded SpriteSheet. The sheet is exported from
+// assets/floor-tile-1.aseprite, the original tiles on one layer and the
+// component art on another; copy the export to test/assets as well.
 func LoadSpriteSheet(tileSize int) (*SpriteSheet, error) {
 	data, err := os.ReadFile("assets/floor-tile-1.png")
 	if err != nil {
@@ -64,13 +66,30 @@ func LoadSpriteSheet(tileSize int) (*SpriteSheet, error) {
 	// s.Portal = spriteAt(5, 6)
 
 	SpriteSet = map[string]*Sprite{
-		"floor":           {Image: spriteAt(0, 0), DrawOrder: 0},
-		"pipe_enter_left": {Image: spriteAt(3, 2), DrawOrder: 10},
-		"reservoir_full":  {Image: spriteAt(2, 0), DrawOrder: 5},
-		"reservoir_high":  {Image: spriteAt(2, 1), DrawOrder: 5},
-		"reservoir_mid":   {Image: spriteAt(2, 2), DrawOrder: 5},
-		"reservoir_low":   {Image: spriteAt(2, 3), DrawOrder: 5},
-		"reservoir_empty": {Image: spriteAt(2, 4), DrawOrder: 5},
+		"floor":             {Image: spriteAt(0, 0), DrawOrder: 0},
+		"pipe_enter_left":   {Image: spriteAt(3, 2), DrawOrder: 10},
+		"reservoir_full":    {Image: spriteAt(2, 0), DrawOrder: 5},
+		"reservoir_high":    {Image: spriteAt(2, 1), DrawOrder: 5},
+		"reservoir_mid":     {Image: spriteAt(2, 2), DrawOrder: 5},
+		"reservoir_low":     {Image: spriteAt(2, 3), DrawOrder: 5},
+		"reservoir_empty":   {Image: spriteAt(2, 4), DrawOrder: 5},
+		"generator_idle":    {Image: spriteAt(5, 1), DrawOrder: 5},
+		"generator_running": {Image: spriteAt(6, 1), DrawOrder: 5},
+		"consumer":          {Image: spriteAt(7, 1), DrawOrder: 5},
+		"wire":              {Image: spriteAt(3, 1), DrawOrder: 2},
+		"pump":              {Image: spriteAt(8, 1), DrawOrder: 6},
+		"valve_open":        {Image: spriteAt(5, 2), DrawOrder: 11},
+		"valve_closed":      {Image: spriteAt(3, 5), DrawOrder: 11},
+		"hopper":            {Image: spriteAt(6, 2), DrawOrder: 5},
+		"conveyor":          {Image: spriteAt(7, 2), DrawOrder: 2},
+		"feeder":            {Image: spriteAt(8, 2), DrawOrder: 6},
+		"furnace_cold":      {Image: spriteAt(5, 0), DrawOrder: 5},
+		"furnace_lit":       {Image: spriteAt(6, 0), DrawOrder: 5},
+		"reactor":           {Image: spriteAt(7, 0), DrawOrder: 5},
+		"outfall":           {Image: spriteAt(8, 0), DrawOrder: 1},
+		"junction":          {Image: spriteAt(3, 6), DrawOrder: 10},
+		"leaking":           {Image: spriteAt(5, 5), DrawOrder: 12},
+		"burst":             {Image: spriteAt(6, 5), DrawOrder: 12},
 	}
 
 	return s, nil
@@ -0,0 +1,103 @@
+package game
+
+import "math"
+
+const (
+	AmbientTemp       = 20.0  // °C
+	HeatTransferCoeff = 500.0 // W/(m^2·K) between connected components
+	AmbientLossCoeff  = 5.0   // W/(m^2·K) from a component to the air
+)
+
+// HeatCapacity returns the joules needed to warm s's contents by one degree.
+func HeatCapacity(s *Structurals) float64 {
+	if s == nil {
+		return 0
+	}
+	contents, amounts := s.portions()
+	c := 0.0
+	for i, m := range contents {
+		c += amounts[i] * m.SpecificHeat
+	}
+	return c
+}
+
+// QueueHeat adds joules of thermal energy to be applied with the next
+// ApplyPending. Negative values remove heat.
+func (s *Structurals) QueueHeat(joules float64) {
+	s.PendingHeat += joules
+}
+
+// exposedArea returns the surface through which c exchanges heat.
+func exposedArea(c Component) float64 {
+	if p, ok := c.(*Pipe); ok {
+		return 2 * math.Pi * p.Radius * p.Length
+	}
+	return c.GetStructurals().Area
+}
+
+// conduct queues the heat that flows between a pipe and each of its ends
+// over dt seconds. The exchange never overshoots the temperature the two
+// would settle at.
+func (s *System) conduct(dt float64) {
+	exchange := func(a, b Component, area float64) {
+		if a == nil || b == nil {
+			return
+		}
+		sa, sb := a.GetStructurals(), b.GetStructurals()
+		ca, cb := HeatCapacity(sa), HeatCapacity(sb)
+		if ca == 0 || cb == 0 {
+			return
+		}
+		dT := sa.CurrentHeat - sb.CurrentHeat
+		q := HeatTransferCoeff * area * dT * dt
+		if limit := math.Abs(dT) * ca * cb / (ca + cb); math.Abs(q) > limit {
+			q = math.Copysign(limit, q)
+		}
+		sa.QueueHeat(-q)
+		sb.QueueHeat(q)
+	}
+
+	for _, p := range s.Pipes {
+		if p == nil {
+			continue
+		}
+		exchange(p.From, p, p.Area)
+		exchange(p, p.To, p.Area)
+	}
+}
+
+// loseHeat queues the heat every component of the network gives to, or
+// takes from, the surrounding air over dt seconds.
+func (s *System) loseHeat(dt float64) {
+	for _, c := range s.Network() {
+		st := c.GetStructurals()
+		capacity := HeatCapacity(st)
+		if capacity == 0 {
+			continue
+		}
+		dT := st.CurrentHeat - AmbientTemp
+		q := AmbientLossCoeff * exposedArea(c) * dT * dt
+		if limit := math.Abs(dT) * capacity; math.Abs(q) > limit {
+			q = math.Copysign(limit, q)
+		}
+		st.QueueHeat(-q)
+		s.account(nil, 0, -q)
+	}
+}
+
+// checkHeat flags s when its temperature has passed MaxHeat.
+func checkHeat(s *Structurals) {
+	s.Overheated = s.MaxHeat > 0 && s.CurrentHeat > s.MaxHeat
+}
+
+// Overheated returns every component of the network that ran past its
+// MaxHeat on the last step.
+func (s *System) Overheated() []Component {
+	var hot []Component
+	for _, c := range s.Network() {
+		if c.GetStructurals().Overheated {
+			hot = append(hot, c)
+		}
+	}
+	return hot
+}
@@ -15,6 +15,7 @@ type Sprite struct {
 // sprites may be added to a Tile.
 type Tile struct {
 	entities []*Entity
+	Spill    Structurals // Material that leaked out onto the tile
 }
 
 func (t *Tile) Entities() []*Entity {
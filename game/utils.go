@@ -32,6 +32,13 @@ func Identifier(c Component) string {
 	return c.GetIdentifier()
 }
 
+func Qty(s *Structurals) float64 {
+	if s == nil {
+		return 0
+	}
+	return s.Quantity
+}
+
 func Cap(s *Structurals) float64 {
 	if s == nil {
 		return 0
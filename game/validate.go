@@ -0,0 +1,223 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IssueKind says what System.Validate found wrong.
+type IssueKind int
+
+const (
+	DanglingPipe    IssueKind = iota // A pipe with a nil From or To
+	UnregisteredEnd                  // A pipe end missing from System.Nodes
+	DuplicateNode                    // A component registered more than once
+	DuplicatePipe                    // A pipe registered more than once
+	IsolatedNetwork                  // Components cut off from the rest of the plant
+	UnheatedTarget                   // A furnace aimed at something outside the pipe network
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case DanglingPipe:
+		return "dangling pipe"
+	case UnregisteredEnd:
+		return "unregistered pipe end"
+	case DuplicateNode:
+		return "duplicate node"
+	case DuplicatePipe:
+		return "duplicate pipe"
+	case IsolatedNetwork:
+		return "isolated network"
+	case UnheatedTarget:
+		return "unheated target"
+	}
+	return "unknown issue"
+}
+
+// ValidationError is a single problem with the layout of a System.
+type ValidationError struct {
+	Kind       IssueKind
+	Component  Component   // The component at fault
+	Components []Component // Every member, for IsolatedNetwork
+	Detail     string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Kind, Identifier(e.Component), e.Detail)
+}
+
+// ValidationErrors is every problem Validate found.
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	lines := make([]string, len(v))
+	for i, e := range v {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap lets errors.As and errors.Is reach each ValidationError.
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, e := range v {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Validate checks the layout of the system and returns a ValidationErrors
+// listing every problem, or nil when there are none. It reports pipes with
+// a missing end, pipe ends that are not registered in Nodes, components or
+// pipes registered twice, furnaces heating something outside the pipe
+// network, and groups of components cut off from the largest part of the
+// plant. Separate plants are allowed, so callers may treat IsolatedNetwork
+// as a warning.
+func (s *System) Validate() error {
+	var issues ValidationErrors
+	report := func(kind IssueKind, c Component, format string, args ...any) {
+		issues = append(issues, &ValidationError{Kind: kind, Component: c, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	nodes := make(map[Component]bool, len(s.Nodes))
+	for _, n := range s.Nodes {
+		if n == nil {
+			continue
+		}
+		if nodes[n] {
+			report(DuplicateNode, n, "registered in Nodes more than once")
+		}
+		nodes[n] = true
+	}
+
+	pipes := make(map[*Pipe]bool, len(s.Pipes))
+	for _, p := range s.Pipes {
+		if p == nil {
+			continue
+		}
+		if pipes[p] {
+			report(DuplicatePipe, p, "registered in Pipes more than once")
+			continue
+		}
+		pipes[p] = true
+		if nodes[p] {
+			report(DuplicateNode, p, "registered in both Nodes and Pipes")
+		}
+
+		switch {
+		case p.From == nil && p.To == nil:
+			report(DanglingPipe, p, "not connected at either end")
+		case p.From == nil:
+			report(DanglingPipe, p, "no From end")
+		case p.To == nil:
+			report(DanglingPipe, p, "no To end")
+		}
+		for _, end := range []Component{p.From, p.To} {
+			if end == nil {
+				continue
+			}
+			if _, isPipe := end.(*Pipe); !isPipe && !nodes[end] {
+				report(UnregisteredEnd, p, "end %s is not in Nodes", Identifier(end))
+			}
+		}
+	}
+
+	// Queued heat is only applied across the network, see Furnace.Update.
+	network := make(map[Component]bool)
+	for _, c := range s.Network() {
+		network[c] = true
+	}
+	for _, m := range s.Machines {
+		if f, ok := m.(*Furnace); ok && f.Target != nil && !network[f.Target] {
+			report(UnheatedTarget, f, "target %s is not in the pipe network", Identifier(f.Target))
+		}
+	}
+
+	groups := s.groups()
+	largest := 0
+	for i, g := range groups {
+		if len(g) > len(groups[largest]) {
+			largest = i
+		}
+	}
+	for i, g := range groups {
+		if i == largest {
+			continue
+		}
+		issues = append(issues, &ValidationError{
+			Kind:       IsolatedNetwork,
+			Component:  g[0],
+			Components: g,
+			Detail:     fmt.Sprintf("%d components not connected to the rest", len(g)),
+		})
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
+// links returns the components c is tied to by pipes, belts, feeders and
+// the like. Valves are part of their pipe, so they do not count.
+func links(c Component) []Component {
+	var l []Component
+	for _, r := range refs(c) {
+		if _, valve := r.to.(*Valve); !valve {
+			l = append(l, r.to)
+		}
+	}
+	return l
+}
+
+// groups splits every component of the system into sets that are connected
+// to each other, in a stable order.
+func (s *System) groups() [][]Component {
+	var all []Component
+	seen := make(map[Component]bool)
+	add := func(c Component) {
+		if c != nil && !seen[c] {
+			seen[c] = true
+			all = append(all, c)
+		}
+	}
+	for _, c := range s.Network() {
+		add(c)
+	}
+	for _, m := range s.Machines {
+		add(m)
+	}
+
+	adj := make(map[Component][]Component)
+	for _, c := range all {
+		for _, o := range links(c) {
+			adj[c] = append(adj[c], o)
+			adj[o] = append(adj[o], c)
+		}
+	}
+
+	var groups [][]Component
+	done := make(map[Component]bool)
+	for _, c := range all {
+		if done[c] {
+			continue
+		}
+		var g []Component
+		stack := []Component{c}
+		done[c] = true
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			g = append(g, n)
+			for _, o := range adj[n] {
+				if !done[o] {
+					done[o] = true
+					stack = append(stack, o)
+				}
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
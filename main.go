@@ -1,7 +1,11 @@
 package main
 
 import (
+	"flag"
+	"io"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -14,11 +18,43 @@ const (
 )
 
 func main() {
+	logLevel := flag.String("log-level", "warn", "simulation log level: trace, debug, info, warn or error")
+	logTrace := flag.String("log-trace", "", "comma separated identifiers of the components to trace, all if empty")
+	logFile := flag.String("log-file", "", "write simulation logs to this file instead of stderr")
+	levelFile := flag.String("level", "", "level file to play, "+game.DefaultLevel+" if empty")
+	flag.Parse()
+
+	level, err := game.ParseLogLevel(*logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var out io.Writer = os.Stderr
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	var trace []string
+	if *logTrace != "" {
+		trace = strings.Split(*logTrace, ",")
+	}
+	game.SetLogging(out, level, trace...)
+
 	// Create game with the level
 	g, err := game.NewGame()
 	if err != nil {
 		log.Fatal(err)
 	}
+	if *levelFile != "" {
+		l, err := game.LoadLevel(*levelFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.SetLevel(l)
+	}
 	// --- Run Game ---
 	ebiten.SetWindowSize(screenWidth*2, screenHeight*2)
 	ebiten.SetWindowTitle("My generator game")
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// leaker moves mass from From to To without checking that From holds it, so
+// ApplyPending clamps From at zero and mass appears from nowhere.
+type leaker struct {
+	mockComponent
+	From, To *game.Reservoir
+}
+
+func (l *leaker) Update(s *game.System, dt float64) {
+	mass := 2 * l.From.AmountOf(game.Water)
+	l.From.QueueMaterial(game.Water, -mass)
+	l.To.QueueMaterial(game.Water, mass)
+}
+
+func TestAuditor_Balanced(t *testing.T) {
+	// Hot water draining down a pipe, losing heat to the air.
+	a, b := newSink("A"), newSink("B")
+	a.BaseElevation = 5
+	a.CurrentHeat, b.CurrentHeat = 80, 20
+	a.AddMaterial(game.Water, 5000)
+	pipes := []*game.Pipe{game.NewPipe(a, b, 10, 0.2)}
+
+	// A boiler raising steam and feeding it on.
+	boiler := &game.Boiler{
+		Structurals: game.Structurals{MaxVolume: 10, Area: 2, CurrentHeat: 95, Stratified: true, DrawTop: true},
+		HeatInput:   5e6,
+	}
+	boiler.AddMaterial(game.Water, 2000)
+	drum := newSink("D")
+	pipes = append(pipes, game.NewPipe(boiler, drum, 5, 0.1))
+
+	// A furnace, a reactor, a leaking vessel and an outfall.
+	tank := newSink("T")
+	f := newFurnace(10, tank)
+	core := newCore()
+	core.SetPower(1)
+	leaky := pressurised(1)
+	river := &game.Outfall{Structurals: game.Structurals{MaxVolume: 100, Area: 10}}
+	spring := newSink("S")
+	spring.AddMaterial(game.Water, 3000)
+	pipes = append(pipes, game.NewPipe(spring, river, 10, 0.2))
+
+	s := &game.System{
+		Nodes:    []game.Component{a, b, boiler, drum, tank, core, leaky, river, spring},
+		Pipes:    pipes,
+		Machines: []game.Component{f},
+		Audit:    &game.Auditor{Strict: true},
+	}
+	s.StepN(300)
+
+	if err := s.Audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if boiler.AmountOf(game.Steam) <= 0 || len(s.Leaks) == 0 || river.Discharged <= 0 || f.Burnt <= 0 {
+		t.Error("scenario did not exercise steam, leaks, outfall and furnace")
+	}
+	if got := s.Audit.Last.After.Mass["water"]; got <= 0 {
+		t.Errorf("water tally = %v, want the water and steam held", got)
+	}
+}
+
+func TestAuditor_CatchesClamping(t *testing.T) {
+	from, to := newSink("F"), newSink("T")
+	from.AddMaterial(game.Water, 100)
+	s := &game.System{
+		Nodes:    []game.Component{from, to},
+		Machines: []game.Component{&leaker{From: from, To: to}},
+		Audit:    &game.Auditor{},
+	}
+	s.StepN(3)
+
+	if s.Audit.Drifted == 0 {
+		t.Fatal("auditor missed mass created by clamping")
+	}
+	if got := s.Audit.Drifts[0].Drift().Mass["water"]; got < 99 || got > 101 {
+		t.Errorf("water drift = %v kg, want ~100", got)
+	}
+	if err := s.Audit.Err(); err == nil || !strings.Contains(err.Error(), "water") {
+		t.Errorf("Err() = %v, want the water drift", err)
+	}
+
+	strict := &game.System{
+		Nodes:    []game.Component{from, to},
+		Machines: []game.Component{&leaker{From: from, To: to}},
+		Audit:    &game.Auditor{Strict: true},
+	}
+	from.AddMaterial(game.Water, 100)
+	defer func() {
+		if recover() == nil {
+			t.Error("strict auditor did not panic")
+		}
+	}()
+	strict.Step()
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestClock_Defaults(t *testing.T) {
+	var c game.Clock
+	if c.StepSize() != game.TimeStep {
+		t.Errorf("StepSize() = %v, want %v", c.StepSize(), game.TimeStep)
+	}
+	if c.SpeedFactor() != 1 {
+		t.Errorf("SpeedFactor() = %v, want 1", c.SpeedFactor())
+	}
+}
+
+func TestClock_Advance(t *testing.T) {
+	tests := []struct {
+		name   string
+		clock  game.Clock
+		frames int
+		want   int
+	}{
+		{name: "1x takes ten frames per step", clock: game.Clock{}, frames: 30, want: 3},
+		{name: "Half speed", clock: game.Clock{Speed: 0.5}, frames: 40, want: 2},
+		{name: "50x", clock: game.Clock{Speed: 50}, frames: 6, want: 30},
+		{name: "Custom step", clock: game.Clock{Step: 1.0 / 60}, frames: 5, want: 5},
+		{name: "Paused", clock: game.Clock{Paused: true}, frames: 100, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			for range tt.frames {
+				got += tt.clock.Advance(1.0 / game.TicksPerSecond)
+			}
+			if got != tt.want {
+				t.Errorf("Advance() steps = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClock_AdvanceCapsCatchUp(t *testing.T) {
+	var c game.Clock
+	if got := c.Advance(3600); got != game.MaxStepsPerAdvance {
+		t.Errorf("Advance(3600) = %d, want %d", got, game.MaxStepsPerAdvance)
+	}
+	if got := c.Advance(0); got != 0 {
+		t.Errorf("Advance(0) after cap = %d, want 0", got)
+	}
+}
+
+func TestClock_Speed(t *testing.T) {
+	var c game.Clock
+	c.SetSpeed(1000)
+	if c.SpeedFactor() != game.MaxSpeed {
+		t.Errorf("SetSpeed(1000) = %v, want %v", c.SpeedFactor(), game.MaxSpeed)
+	}
+	c.SetSpeed(0.01)
+	if c.SpeedFactor() != game.MinSpeed {
+		t.Errorf("SetSpeed(0.01) = %v, want %v", c.SpeedFactor(), game.MinSpeed)
+	}
+
+	c.SetSpeed(1)
+	c.Faster()
+	if c.SpeedFactor() != 2 {
+		t.Errorf("Faster() from 1x = %v, want 2", c.SpeedFactor())
+	}
+	c.Slower()
+	c.Slower()
+	if c.SpeedFactor() != 0.5 {
+		t.Errorf("Slower() twice from 2x = %v, want 0.5", c.SpeedFactor())
+	}
+	c.Slower()
+	if c.SpeedFactor() != 0.5 {
+		t.Errorf("Slower() at minimum = %v, want 0.5", c.SpeedFactor())
+	}
+}
+
+func TestSystem_StepN(t *testing.T) {
+	s := &game.System{Clock: game.Clock{Paused: true}}
+	s.StepN(4)
+	if s.Clock.Steps != 4 {
+		t.Errorf("Clock.Steps = %d, want 4", s.Clock.Steps)
+	}
+	if want := 4 * game.TimeStep; s.Clock.Time < want-1e-9 || s.Clock.Time > want+1e-9 {
+		t.Errorf("Clock.Time = %v, want %v", s.Clock.Time, want)
+	}
+
+	// A paused system does not advance on its own.
+	s.Tick()
+	if s.Clock.Steps != 4 || s.Ticks != 0 {
+		t.Errorf("paused Tick() advanced: steps=%d ticks=%d", s.Clock.Steps, s.Ticks)
+	}
+}
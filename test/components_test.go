@@ -147,3 +147,211 @@ func TestPipe_GetStructurals(t *testing.T) {
 		}
 	})
 }
+
+func TestGenerator_HeadFraction(t *testing.T) {
+	tests := []struct {
+		name string
+		load float64
+		want float64
+	}{
+		{name: "Default", load: 0, want: game.DefaultTurbineLoad},
+		{name: "Custom", load: 0.5, want: 0.5},
+		{name: "Clamped", load: 3, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &game.Generator{Load: tt.load}
+			if got := g.HeadFraction(); got != tt.want {
+				t.Errorf("HeadFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_ProducesPower(t *testing.T) {
+	build := func(withGenerator bool) (*game.System, *game.Reservoir, *game.Generator) {
+		high, low := newSink("H"), newSink("L")
+		high.BaseElevation = 50
+		high.AddMaterial(game.Water, 100000)
+		high.MaxVolume = 200
+		low.BaseElevation = -20
+
+		var mid game.Component = newSink("J")
+		g := &game.Generator{Efficiency: 0.9, Structurals: game.Structurals{MaxVolume: 1, Area: 1}}
+		if withGenerator {
+			mid = g
+		} else {
+			mid.GetStructurals().MaxVolume = 1
+			mid.GetStructurals().Area = 1
+		}
+		s := &game.System{
+			Nodes: []game.Component{high, mid, low},
+			Pipes: []*game.Pipe{game.NewPipe(high, mid, 5, 0.2), game.NewPipe(mid, low, 5, 0.2)},
+		}
+		return s, low, g
+	}
+
+	s, low, g := build(true)
+	sFree, lowFree, _ := build(false)
+	for range 100 {
+		s.Step()
+		sFree.Step()
+	}
+
+	if g.Output <= 0 {
+		t.Fatalf("generator Output = %v, want > 0", g.Output)
+	}
+	if low.Quantity <= 0 {
+		t.Fatal("no water made it through the generator")
+	}
+	if low.Quantity >= lowFree.Quantity {
+		t.Errorf("generator did not load the flow: %v with vs %v without", low.Quantity, lowFree.Quantity)
+	}
+}
+
+func TestPump_Head(t *testing.T) {
+	p := &game.Pump{ShutoffHead: 20, MaxFlow: 0.5, On: true, Speed: 1}
+	if got := p.Head(0); got != 20 {
+		t.Errorf("Head(0) = %v, want 20", got)
+	}
+	if got := p.Head(0.5); got != 0 {
+		t.Errorf("Head(MaxFlow) = %v, want 0", got)
+	}
+	if got := p.Head(0.25); math.Abs(got-15) > 1e-9 {
+		t.Errorf("Head(MaxFlow/2) = %v, want 15", got)
+	}
+
+	p.SetSpeed(0.5)
+	if got := p.Head(0); math.Abs(got-5) > 1e-9 {
+		t.Errorf("Head(0) at half speed = %v, want 5", got)
+	}
+	p.SetSpeed(7)
+	if p.Speed != 1 {
+		t.Errorf("SetSpeed(7) = %v, want 1", p.Speed)
+	}
+
+	p.SetOn(false)
+	if got := p.Head(0); got != 0 {
+		t.Errorf("Head(0) while off = %v, want 0", got)
+	}
+}
+
+func TestPump_PowerDemand(t *testing.T) {
+	p := &game.Pump{ShutoffHead: 20, MaxFlow: 0.5, On: true, Speed: 0.5, RatedPower: 8000}
+	if got := p.PowerDemand(); got != 1000 {
+		t.Errorf("PowerDemand() = %v, want 1000", got)
+	}
+	if got := p.Head(0); got != 0 {
+		t.Errorf("Head(0) with no power = %v, want 0", got)
+	}
+	p.Supply(1000)
+	if got := p.Head(0); math.Abs(got-5) > 1e-9 {
+		t.Errorf("Head(0) fully powered = %v, want 5", got)
+	}
+}
+
+func TestPump_LiftsWater(t *testing.T) {
+	tests := []struct {
+		name     string
+		on       bool
+		powered  bool
+		lift     float64
+		wantLift bool
+	}{
+		{name: "Off", on: false, lift: 5, wantLift: false},
+		{name: "On without power", on: true, powered: false, lift: 5, wantLift: false},
+		{name: "On with power", on: true, powered: true, lift: 5, wantLift: true},
+		{name: "On with power, 15 m", on: true, powered: true, lift: 15, wantLift: true},
+		{name: "Above shutoff head", on: true, powered: true, lift: 25, wantLift: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, high := newSink("L"), newSink("H")
+			low.AddMaterial(game.Water, 5000)
+			high.BaseElevation = tt.lift
+
+			pipe := game.NewPipe(low, high, 10, 0.2)
+			pump := &game.Pump{Pipe: pipe, ShutoffHead: 20, MaxFlow: 0.5, On: tt.on, Speed: 1, RatedPower: 1000}
+			s := &game.System{
+				Nodes:    []game.Component{low, high},
+				Pipes:    []*game.Pipe{pipe},
+				Machines: []game.Component{pump},
+			}
+			s.Grid.AddWire(&game.Wire{X: 0, Y: 0})
+			s.Grid.Attach(pump, 0, 0)
+			if tt.powered {
+				s.Grid.Attach(&game.Generator{Output: 1e4}, 0, 0)
+			}
+
+			// Once the pipe has filled, water should keep rising every step
+			// at no more than the pump's MaxFlow.
+			maxFlow := pump.MaxFlow * game.Water.Density
+			prev := 0.0
+			for i := range 40 {
+				s.Step()
+				if pipe.Flow > maxFlow+1e-6 {
+					t.Fatalf("step %d: flow %v kg/s, over MaxFlow %v", i, pipe.Flow, maxFlow)
+				}
+				if i >= 20 {
+					if rising := high.Quantity > prev; rising != tt.wantLift {
+						t.Fatalf("step %d: water rising = %v (qty %v, was %v), want %v", i, rising, high.Quantity, prev, tt.wantLift)
+					}
+				}
+				prev = high.Quantity
+			}
+		})
+	}
+}
+
+func TestValve_SetOpening(t *testing.T) {
+	v := &game.Valve{}
+	v.SetOpening(0.4)
+	if v.Opening != 0.4 {
+		t.Errorf("SetOpening(0.4) = %v", v.Opening)
+	}
+	v.SetOpening(1.5)
+	if v.Opening != 1 {
+		t.Errorf("SetOpening(1.5) = %v, want 1", v.Opening)
+	}
+	v.Toggle()
+	if v.Opening != 0 {
+		t.Errorf("Toggle() open valve = %v, want 0", v.Opening)
+	}
+	v.Toggle()
+	if v.Opening != 1 {
+		t.Errorf("Toggle() shut valve = %v, want 1", v.Opening)
+	}
+}
+
+func TestPipe_OpenFraction(t *testing.T) {
+	p := game.NewPipe(nil, nil, 1, 1)
+	if got := p.OpenFraction(); got != 1 {
+		t.Errorf("OpenFraction() with no valve = %v, want 1", got)
+	}
+	v := &game.Valve{Opening: 0.25}
+	v.Fit(p)
+	if got := p.OpenFraction(); got != 0.25 {
+		t.Errorf("OpenFraction() = %v, want 0.25", got)
+	}
+}
+
+func TestValve_ThrottlesFlow(t *testing.T) {
+	moved := func(opening float64) float64 {
+		src, dst := newSink("S"), newSink("D")
+		src.BaseElevation = 10
+		src.AddMaterial(game.Water, 5000)
+		p := game.NewPipe(src, dst, 10, 0.2)
+		(&game.Valve{Opening: opening}).Fit(p)
+		s := &game.System{Nodes: []game.Component{src, dst}, Pipes: []*game.Pipe{p}}
+		s.StepN(5)
+		return 5000 - src.Quantity
+	}
+
+	open, half, shut := moved(1), moved(0.5), moved(0)
+	if shut != 0 {
+		t.Errorf("shut valve let %v through", shut)
+	}
+	if !(half > 0 && half < open) {
+		t.Errorf("half-open valve moved %v, want between 0 and %v", half, open)
+	}
+}
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// drawn reports whether any entity in l, or on its tiles, draws c.
+func drawn(l *game.Level, c game.Component) bool {
+	for _, e := range l.Entities() {
+		if e.Component == c {
+			return true
+		}
+	}
+	w, h := l.Size()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for _, e := range l.Tile(x, y).Entities() {
+				if e.Component == c {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// coalInWorld returns the coal held by everything in l's System and spilled
+// on its tiles.
+func coalInWorld(l *game.Level) float64 {
+	total := 0.0
+	comps := append(append([]game.Component(nil), l.System.Nodes...), l.System.Machines...)
+	for _, c := range comps {
+		total += c.GetStructurals().AmountOf(game.Coal)
+	}
+	w, h := l.Size()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			total += l.Tile(x, y).Spill.AmountOf(game.Coal)
+		}
+	}
+	return total
+}
+
+func TestLevel_Despawn_Spill(t *testing.T) {
+	l := setupTestLevel(t)
+	a, p1 := l.System.Nodes[0], l.System.Pipes[0]
+	pumpEnt, _ := l.Spawn(game.EntityConfig{Type: "Pump", X: 2, Y: 2, Identifier: "PU"})
+	pump := pumpEnt.Component.(*game.Pump)
+	pump.Pipe = p1
+	valveEnt, _ := l.Spawn(game.EntityConfig{Type: "Valve", X: 1, Y: 2, Identifier: "V"})
+	valve := valveEnt.Component.(*game.Valve)
+	valve.Fit(p1)
+	pipeQty := p1.Quantity
+
+	refund, err := l.Despawn(a, game.SpillContents)
+	if err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if refund.Quantity != 0 {
+		t.Errorf("spilling refunded %v", refund.Quantity)
+	}
+	if got := l.Tile(1, 1).Spill.AmountOf(game.Water); got != 2000 {
+		t.Errorf("A spilled %v kg, want 2000", got)
+	}
+	if got := l.Tile(1, 2).Spill.AmountOf(game.Water); got != pipeQty {
+		t.Errorf("P1 spilled %v kg, want %v", got, pipeQty)
+	}
+
+	for _, c := range []game.Component{a, p1} {
+		if drawn(l, c) {
+			t.Errorf("%s still has an entity", game.Identifier(c))
+		}
+		if _, err := l.Lookup(game.Identifier(c)); err == nil {
+			t.Errorf("%s still spawned", game.Identifier(c))
+		}
+	}
+	if len(l.System.Nodes) != 1 || len(l.System.Pipes) != 0 {
+		t.Errorf("system has %d nodes %d pipes, want 1 and 0", len(l.System.Nodes), len(l.System.Pipes))
+	}
+	if pump.Pipe != nil || !drawn(l, pump) {
+		t.Error("pump not left in place unattached")
+	}
+	if !drawn(l, valve) {
+		t.Error("valve fitted to a removed pipe was removed")
+	}
+
+	// The idle pump is a plant of its own, which is fine.
+	if got := kinds(t, l.System.Validate()); len(got) != 1 || got[game.IsolatedNetwork] != 1 {
+		t.Errorf("Validate() found %v", got)
+	}
+	l.System.StepN(10)
+	if _, err := l.Snapshot(); err != nil {
+		t.Errorf("Snapshot() error = %v", err)
+	}
+}
+
+func TestLevel_Despawn_Refund(t *testing.T) {
+	l := setupTestLevel(t)
+	a := l.System.Nodes[0]
+	before := totalQuantity(l.System)
+
+	refund, err := l.Despawn(a, game.RefundContents)
+	if err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if got := refund.AmountOf(game.Water); math.Abs(got-(before-totalQuantity(l.System))) > 1e-9 || got < 2000 {
+		t.Errorf("refunded %v kg, want everything A and P1 held", got)
+	}
+	if l.Tile(1, 1).Spill.Quantity != 0 {
+		t.Error("refunded contents were spilled too")
+	}
+
+	b := l.System.Nodes[0]
+	b.GetStructurals().AddMaterial(game.Water, 100)
+	refund, err = l.Despawn(b, game.VanishContents)
+	if err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if refund.Quantity != 0 || l.Tile(1, 3).Spill.Quantity != 0 {
+		t.Error("vanished contents turned up")
+	}
+}
+
+func TestLevel_Despawn_Machines(t *testing.T) {
+	l := busyLevel(t)
+	var belt *game.Conveyor
+	var feeder *game.Feeder
+	var furnace *game.Furnace
+	for _, m := range l.System.Machines {
+		switch m := m.(type) {
+		case *game.Conveyor:
+			belt = m
+		case *game.Feeder:
+			feeder = m
+		case *game.Furnace:
+			furnace = m
+		}
+	}
+	hopper, _ := l.Lookup("H")
+	hopper.GetStructurals().AddMaterial(game.Coal, 50)
+	l.System.Step()
+	if len(belt.Loads) == 0 {
+		t.Fatal("belt picked nothing up from the hopper")
+	}
+	before := coalInWorld(l)
+
+	refund, err := l.Despawn(belt, game.RefundContents)
+	if err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if drawn(l, belt) {
+		t.Error("belt tiles left behind")
+	}
+	if got, after := refund.AmountOf(game.Coal), coalInWorld(l); math.Abs(got+after-before) > 1e-9 {
+		t.Errorf("refunded %v kg coal and %v kg left, want %v in all", got, after, before)
+	}
+
+	if _, err := l.Despawn(hopper, game.VanishContents); err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if feeder.Source != nil || feeder.Target != furnace {
+		t.Error("feeder still drawing from the removed hopper")
+	}
+	if _, err := l.Despawn(l.System.Nodes[0], game.SpillContents); err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if furnace.Target != nil {
+		t.Error("furnace still heating the removed tank")
+	}
+
+	l.System.StepN(10)
+	r := roundTrip(t, l)
+	if len(r.System.Machines) != len(l.System.Machines) {
+		t.Errorf("restored %d machines, want %d", len(r.System.Machines), len(l.System.Machines))
+	}
+}
+
+func TestLevel_Despawn_NotSpawned(t *testing.T) {
+	l := setupTestLevel(t)
+	if _, err := l.Despawn(newSink("X"), game.SpillContents); err == nil {
+		t.Error("Despawn() of a component not in the level succeeded")
+	}
+	a := l.System.Nodes[0]
+	l.System.Pipes = append(l.System.Pipes, nil) // Must not trip up Despawn
+	if _, err := l.Despawn(a, game.VanishContents); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Despawn(a, game.VanishContents); err == nil {
+		t.Error("Despawn() twice succeeded")
+	}
+}
+
+func TestLevel_Despawn_NoTile(t *testing.T) {
+	l := setupTestLevel(t)
+	// Spawned off the map, so nothing is drawn and there is no floor.
+	ent, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 9, Y: 9, Identifier: "OFF", InitialQty: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drawn(l, ent.Component) {
+		t.Fatal("off-map reservoir has an entity")
+	}
+	before := totalQuantity(l.System)
+
+	refund, err := l.Despawn(ent.Component, game.SpillContents)
+	if err != nil {
+		t.Fatalf("Despawn() error = %v", err)
+	}
+	if got := refund.AmountOf(game.Water); got != 300 || before-totalQuantity(l.System) != 300 {
+		t.Errorf("refunded %v kg with nowhere to spill, want 300", got)
+	}
+}
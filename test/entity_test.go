@@ -110,3 +110,83 @@ func TestNewPipeEntity(t *testing.T) {
 		t.Error("NewPipeEntity() selector is nil")
 	}
 }
+
+func TestOutputSelector(t *testing.T) {
+	game.SpriteSet = make(map[string]*game.Sprite)
+	game.SpriteSet["on"] = &game.Sprite{DrawOrder: 1}
+	game.SpriteSet["off"] = &game.Sprite{DrawOrder: 2}
+	sel := game.OutputSelector("on", "off")
+
+	g := &game.Generator{}
+	e := &game.Entity{Component: g}
+	if s := sel(e); s == nil || s.DrawOrder != 2 {
+		t.Error("OutputSelector (idle) failed")
+	}
+	g.Output = 100
+	if s := sel(e); s == nil || s.DrawOrder != 1 {
+		t.Error("OutputSelector (running) failed")
+	}
+}
+
+func TestNewGeneratorEntity(t *testing.T) {
+	e := game.NewGeneratorEntity(0, 0, &game.Generator{}, 1)
+	if e.Selector == nil {
+		t.Error("NewGeneratorEntity() selector is nil")
+	}
+}
+
+func TestOpeningSelector(t *testing.T) {
+	game.SpriteSet = make(map[string]*game.Sprite)
+	game.SpriteSet["open"] = &game.Sprite{DrawOrder: 1}
+	game.SpriteSet["shut"] = &game.Sprite{DrawOrder: 2}
+	sel := game.OpeningSelector("open", "shut")
+
+	v := &game.Valve{Opening: 0.3}
+	e := &game.Entity{Component: v}
+	if s := sel(e); s == nil || s.DrawOrder != 1 {
+		t.Error("OpeningSelector (open) failed")
+	}
+	v.Opening = 0
+	if s := sel(e); s == nil || s.DrawOrder != 2 {
+		t.Error("OpeningSelector (shut) failed")
+	}
+}
+
+func TestConditionSelector(t *testing.T) {
+	game.SpriteSet = make(map[string]*game.Sprite)
+	game.SpriteSet["base"] = &game.Sprite{DrawOrder: 1}
+	game.SpriteSet["leak"] = &game.Sprite{DrawOrder: 2}
+	game.SpriteSet["burst"] = &game.Sprite{DrawOrder: 3}
+	sel := game.ConditionSelector(game.StaticSpriteSelector("base"), "leak", "burst")
+
+	r := &game.Reservoir{}
+	e := &game.Entity{Component: r}
+	for cond, want := range map[game.Condition]int{
+		game.Intact:  1,
+		game.Warning: 1,
+		game.Leaking: 2,
+		game.Burst:   3,
+	} {
+		r.Condition = cond
+		if s := sel(e); s == nil || s.DrawOrder != want {
+			t.Errorf("ConditionSelector (%v) = %v, want draw order %d", cond, s, want)
+		}
+	}
+}
+
+func TestFiringSelector(t *testing.T) {
+	game.SpriteSet = make(map[string]*game.Sprite)
+	game.SpriteSet["lit"] = &game.Sprite{DrawOrder: 1}
+	game.SpriteSet["cold"] = &game.Sprite{DrawOrder: 2}
+	sel := game.FiringSelector("lit", "cold")
+
+	f := &game.Furnace{}
+	e := &game.Entity{Component: f}
+	if s := sel(e); s == nil || s.DrawOrder != 2 {
+		t.Error("FiringSelector (cold) failed")
+	}
+	f.Burnt = 0.1
+	if s := sel(e); s == nil || s.DrawOrder != 1 {
+		t.Error("FiringSelector (lit) failed")
+	}
+}
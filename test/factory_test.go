@@ -1,6 +1,8 @@
 package test
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/padilin/gengeno/game"
@@ -60,3 +62,243 @@ func setupTestLevel(t *testing.T) *game.Level {
 	}
 	return l
 }
+
+func TestLevel_Spawn_Boiler(t *testing.T) {
+	l := setupTestLevel(t)
+
+	ent, err := l.Spawn(game.EntityConfig{
+		Type: "Boiler",
+		X:    2, Y: 2,
+		Identifier: "BOIL",
+		InitialQty: 500,
+		HeatInput:  2e5,
+	})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	b, ok := ent.Component.(*game.Boiler)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Boiler", ent.Component)
+	}
+	if b.HeatInput != 2e5 || b.CurrentHeat != game.AmbientTemp || !b.DrawTop {
+		t.Errorf("boiler = heat %v temp %v drawTop %v", b.HeatInput, b.CurrentHeat, b.DrawTop)
+	}
+	if last := l.System.Nodes[len(l.System.Nodes)-1]; last != game.Component(b) {
+		t.Error("boiler not registered with the system")
+	}
+}
+
+func TestLevel_Spawn_Generator(t *testing.T) {
+	l := setupTestLevel(t)
+
+	ent, err := l.Spawn(game.EntityConfig{Type: "Generator", X: 0, Y: 3, Identifier: "G"})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	if ent == nil {
+		t.Fatal("Spawn returned nil entity")
+	}
+	g, ok := ent.Component.(*game.Generator)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Generator", ent.Component)
+	}
+	if g.Efficiency != 0.9 || g.MaxVolume != 10 {
+		t.Errorf("generator defaults = efficiency %v volume %v", g.Efficiency, g.MaxVolume)
+	}
+}
+
+func TestLevel_Spawn_Pump(t *testing.T) {
+	l := setupTestLevel(t)
+
+	ent, err := l.Spawn(game.EntityConfig{Type: "Pump", X: 2, Y: 1, Identifier: "PU", RatedPower: 500})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	pump, ok := ent.Component.(*game.Pump)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Pump", ent.Component)
+	}
+	if !pump.On || pump.Speed != 1 || pump.ShutoffHead != 20 || pump.MaxFlow != 0.5 {
+		t.Errorf("pump defaults = %+v", pump)
+	}
+	if len(l.System.Machines) != 1 || len(l.System.Grid.Devices) != 1 {
+		t.Errorf("pump registered as %d machines %d grid devices, want 1 and 1", len(l.System.Machines), len(l.System.Grid.Devices))
+	}
+}
+
+func TestLevel_Spawn_Valve(t *testing.T) {
+	l := setupTestLevel(t)
+	nodes := len(l.System.Nodes)
+
+	half := 0.5
+	ent, err := l.Spawn(game.EntityConfig{Type: "Valve", X: 1, Y: 2, Identifier: "V1", Opening: &half})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	v, ok := ent.Component.(*game.Valve)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Valve", ent.Component)
+	}
+	if v.Opening != 0.5 {
+		t.Errorf("Opening = %v, want 0.5", v.Opening)
+	}
+	if len(l.System.Nodes) != nodes {
+		t.Error("valve registered as a hydraulic node")
+	}
+
+	ent, _ = l.Spawn(game.EntityConfig{Type: "Valve", X: 1, Y: 2})
+	if ent.Component.(*game.Valve).Opening != 1 {
+		t.Error("valve without Opening did not spawn fully open")
+	}
+}
+
+func TestLevel_Spawn_UnknownType(t *testing.T) {
+	l := setupTestLevel(t)
+	entities := len(l.Entities())
+
+	ent, err := l.Spawn(game.EntityConfig{Type: "Wall", X: 0, Y: 0})
+	if err == nil || ent != nil {
+		t.Fatalf("Spawn(Wall) = %v, %v, want an error", ent, err)
+	}
+	if len(l.Entities()) != entities {
+		t.Error("failed Spawn added an entity")
+	}
+}
+
+func TestRegisterComponent(t *testing.T) {
+	// Registration is global, so only do it once however often tests run.
+	if !slices.Contains(game.ComponentKinds(), "TestTank") {
+		game.RegisterComponent("TestTank", game.ComponentKind{
+			Defaults: func(c *game.EntityConfig) {
+				if c.MaxVolume == 0 {
+					c.MaxVolume = 42
+				}
+			},
+			Build: func(l *game.Level, c game.EntityConfig) (game.Component, *game.Entity, error) {
+				r := &game.Reservoir{
+					Basics:      game.Basics{Identifier: c.Identifier},
+					Structurals: game.Structurals{MaxVolume: c.MaxVolume, Area: c.Area},
+				}
+				return r, game.NewReservoirEntity(c.X, c.Y, r, 1), nil
+			},
+		})
+	}
+
+	l := setupTestLevel(t)
+	ent, err := l.Spawn(game.EntityConfig{Type: "TestTank", X: 2, Y: 2, Identifier: "T"})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	r := ent.Component.(*game.Reservoir)
+	if r.MaxVolume != 42 || r.Area != 5 {
+		t.Errorf("defaults = volume %v area %v, want 42 and 5", r.MaxVolume, r.Area)
+	}
+	if last := l.System.Nodes[len(l.System.Nodes)-1]; last != game.Component(r) {
+		t.Error("kind without Register not added to System.Nodes")
+	}
+}
+
+func TestComponentKinds(t *testing.T) {
+	kinds := game.ComponentKinds()
+	for _, want := range []string{"Reservoir", "Pipe", "Boiler", "Generator", "Pump", "Valve", "Furnace", "Reactor"} {
+		if !slices.Contains(kinds, want) {
+			t.Errorf("ComponentKinds() = %v, missing %s", kinds, want)
+		}
+	}
+	if !slices.IsSorted(kinds) {
+		t.Errorf("ComponentKinds() = %v, not sorted", kinds)
+	}
+}
+
+func TestLevel_Spawn_Connected(t *testing.T) {
+	l := setupTestLevel(t)
+	pipes := len(l.System.Pipes)
+
+	ent, err := l.Spawn(game.EntityConfig{Type: "Pipe", X: 2, Y: 2, Identifier: "P2", From: "B", To: "A"})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	p := ent.Component.(*game.Pipe)
+	if game.Identifier(p.From) != "B" || game.Identifier(p.To) != "A" {
+		t.Errorf("P2 runs %s -> %s, want B -> A", game.Identifier(p.From), game.Identifier(p.To))
+	}
+	if len(l.System.Pipes) != pipes+1 {
+		t.Error("connected pipe not registered")
+	}
+
+	if _, err := l.Spawn(game.EntityConfig{Type: "Furnace", X: 0, Y: 0, Identifier: "F", To: "A"}); err != nil {
+		t.Fatalf("Spawn furnace failed: %v", err)
+	}
+	f, _ := l.Lookup("F")
+	if f.(*game.Furnace).Target != p.To {
+		t.Error("furnace not aimed at A")
+	}
+
+	pump, err := l.Spawn(game.EntityConfig{Type: "Pump", X: 2, Y: 1, Identifier: "PU", To: "P2"})
+	if err != nil {
+		t.Fatalf("Spawn pump failed: %v", err)
+	}
+	if pump.Component.(*game.Pump).Pipe != p {
+		t.Error("pump not driving P2")
+	}
+	valve, err := l.Spawn(game.EntityConfig{Type: "Valve", X: 2, Y: 3, Identifier: "V", To: "P2"})
+	if err != nil {
+		t.Fatalf("Spawn valve failed: %v", err)
+	}
+	if p.Valve != valve.Component {
+		t.Error("valve not fitted to P2")
+	}
+}
+
+func TestLevel_Spawn_BadReference(t *testing.T) {
+	l := setupTestLevel(t)
+	if _, err := l.Spawn(game.EntityConfig{Type: "Pump", X: 2, Y: 2, Identifier: "PU"}); err != nil {
+		t.Fatal(err)
+	}
+	entities, pipes := len(l.Entities()), len(l.System.Pipes)
+
+	for name, cfg := range map[string]game.EntityConfig{
+		"unknown":      {Type: "Pipe", From: "A", To: "NOWHERE"},
+		"not spawned":  {Type: "Pipe", From: "A", To: "P9"},
+		"unconnected":  {Type: "Reservoir", From: "A"},
+		"furnace from": {Type: "Furnace", From: "A"},
+		"pump on tank": {Type: "Pump", To: "A"},
+		"pipe to pump": {Type: "Pipe", From: "A", To: "PU"},
+		"valve from":   {Type: "Valve", From: "P1"},
+		"belt to pipe": {Type: "Conveyor", X: 3, Y: 2, Path: [][2]int{{3, 3}}, From: "A", To: "P1"},
+	} {
+		if ent, err := l.Spawn(cfg); err == nil {
+			t.Errorf("%s: Spawn = %v, want an error", name, ent)
+		}
+	}
+	if len(l.Entities()) != entities || len(l.System.Pipes) != pipes {
+		t.Error("failed Spawn changed the level")
+	}
+
+	// A second "A" makes the name ambiguous.
+	if _, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 3, Y: 3, Identifier: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Spawn(game.EntityConfig{Type: "Pipe", From: "A", To: "B"}); err == nil || !strings.Contains(err.Error(), `2 components named "A"`) {
+		t.Errorf("ambiguous From: error = %v", err)
+	}
+}
+
+func TestLevel_Spawn_Temperature(t *testing.T) {
+	l := setupTestLevel(t)
+	freezing := 0.0
+	ice, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 2, Y: 2, Identifier: "ICE", Temperature: &freezing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ice.Component.GetStructurals().CurrentHeat; got != 0 {
+		t.Errorf("spawned at %v °C, want 0", got)
+	}
+	warm, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 3, Y: 2, Identifier: "WARM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := warm.Component.GetStructurals().CurrentHeat; got != game.AmbientTemp {
+		t.Errorf("spawned at %v °C with no Temperature, want %v", got, game.AmbientTemp)
+	}
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// pressurised returns a reservoir holding a 10 m column of water, about
+// 2 atm absolute, rated for maxPressure.
+func pressurised(maxPressure float64) *game.Reservoir {
+	r := &game.Reservoir{
+		Basics:      game.Basics{Identifier: "R"},
+		Structurals: game.Structurals{MaxVolume: 100, Area: 1, MaxPressure: maxPressure},
+	}
+	r.AddMaterial(game.Water, 10*game.Water.Density)
+	return r
+}
+
+func TestCheckPressure_Warning(t *testing.T) {
+	r := pressurised(0)
+	sys := &game.System{Nodes: []game.Component{r}}
+	sys.StepN(1)
+	if r.Pressure <= game.AtmosphericPressure {
+		t.Fatalf("Pressure = %v, want above atmospheric", r.Pressure)
+	}
+	if r.Condition != game.Intact {
+		t.Errorf("unrated component Condition = %v, want intact", r.Condition)
+	}
+
+	r.MaxPressure = r.Pressure * 1.05
+	sys.StepN(1)
+	if r.Condition != game.Warning || r.Damage != 0 {
+		t.Errorf("near limit = %v damage %v, want warning with no damage", r.Condition, r.Damage)
+	}
+}
+
+func TestCheckPressure_Escalates(t *testing.T) {
+	r := pressurised(1.2e5)
+	start := r.Quantity
+	sys := &game.System{Nodes: []game.Component{r}}
+
+	seen := map[game.Condition]bool{}
+	leaked := 0.0
+	for range 200 {
+		sys.StepN(1)
+		seen[r.Condition] = true
+		for _, l := range sys.TakeLeaks() {
+			if l.Component != game.Component(r) {
+				t.Fatalf("leak from %v, want R", game.Identifier(l.Component))
+			}
+			leaked += l.Mass
+		}
+		if r.Condition == game.Burst {
+			break
+		}
+	}
+	if !seen[game.Leaking] || !seen[game.Burst] {
+		t.Fatalf("conditions seen = %v, want leaking then burst", seen)
+	}
+	if leaked <= 0 {
+		t.Error("damaged component leaked nothing")
+	}
+	if got := r.Quantity + leaked; math.Abs(got-start) > 1e-6*start {
+		t.Errorf("held + leaked = %v, want %v", got, start)
+	}
+	if d := sys.Damaged(); len(d) != 1 || d[0] != game.Component(r) {
+		t.Errorf("Damaged() = %v, want [R]", d)
+	}
+
+	// A burst component keeps emptying even once the pressure is gone.
+	before := r.Quantity
+	sys.StepN(1)
+	if r.Quantity >= before || r.Condition != game.Burst {
+		t.Errorf("burst = %v quantity %v -> %v, want still burst and losing contents", r.Condition, before, r.Quantity)
+	}
+
+	r.Repair()
+	if r.Condition != game.Intact || r.Damage != 0 {
+		t.Errorf("after Repair = %v damage %v", r.Condition, r.Damage)
+	}
+}
+
+func TestLevel_CollectLeaks(t *testing.T) {
+	l := setupTestLevel(t)
+	ent, err := l.Spawn(game.EntityConfig{
+		Type: "Reservoir",
+		X:    2, Y: 0,
+		Identifier:  "HOT",
+		InitialQty:  100,
+		MaxPressure: 2e5,
+	})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	res := ent.Component.GetStructurals()
+	if res.MaxPressure != 2e5 {
+		t.Errorf("MaxPressure = %v, want 2e5", res.MaxPressure)
+	}
+	res.Condition = game.Burst
+	l.System.StepN(1)
+	l.CollectLeaks()
+
+	spill := l.Tile(2, 0).Spill
+	if spill.Quantity <= 0 || spill.AmountOf(game.Water) != spill.Quantity {
+		t.Errorf("tile spill = %v, want the leaked water", spill.Quantity)
+	}
+	if len(l.System.Leaks) != 0 {
+		t.Error("CollectLeaks left leaks behind")
+	}
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func newFurnace(coal float64, target game.Component) *game.Furnace {
+	f := &game.Furnace{
+		Basics:      game.Basics{Identifier: "F"},
+		Structurals: game.Structurals{MaxVolume: 5, CurrentHeat: game.AmbientTemp},
+		Target:      target,
+		MaxBurn:     0.1,
+		Firing:      1,
+		Efficiency:  0.8,
+	}
+	f.AddMaterial(game.Coal, coal)
+	return f
+}
+
+func TestFurnace_HeatsTarget(t *testing.T) {
+	// No Area means the water loses no heat to the air.
+	r := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 20}}
+	r.AddMaterial(game.Water, 1000)
+	f := newFurnace(10, r)
+	f.SetFiring(0.5)
+	s := &game.System{Nodes: []game.Component{r}, Machines: []game.Component{f}}
+
+	s.StepN(6)
+	burnt := 0.05 * s.Clock.Time
+	if got := 10 - f.Fuel(); math.Abs(got-burnt) > 1e-9 {
+		t.Errorf("burnt %v kg, want %v", got, burnt)
+	}
+	if got := f.AmountOf(game.Ash); math.Abs(got-0.1*burnt) > 1e-9 {
+		t.Errorf("ash = %v, want %v", got, 0.1*burnt)
+	}
+	if math.Abs(f.Exhausted-0.9*burnt) > 1e-9 {
+		t.Errorf("Exhausted = %v, want %v", f.Exhausted, 0.9*burnt)
+	}
+
+	heat := burnt * game.Coal.HeatingValue * 0.8
+	want := 20 + heat/(1000*game.Water.SpecificHeat)
+	if math.Abs(r.CurrentHeat-want) > 1e-9 {
+		t.Errorf("water at %v°C, want %v°C", r.CurrentHeat, want)
+	}
+	if want := 0.05 * game.Coal.HeatingValue * 0.8; math.Abs(f.Output-want) > 1e-6 {
+		t.Errorf("Output = %v W, want %v W", f.Output, want)
+	}
+}
+
+func TestFurnace_TargetOutsideNetwork(t *testing.T) {
+	r := newSink("R")
+	r.AddMaterial(game.Water, 1000)
+	f := newFurnace(10, r)
+	s := &game.System{Machines: []game.Component{f}, Audit: &game.Auditor{Strict: true}}
+	temp := r.CurrentHeat
+
+	s.StepN(10)
+	if f.Burnt <= 0 {
+		t.Fatal("furnace burnt nothing")
+	}
+	if f.Output != 0 || r.PendingHeat != 0 || r.CurrentHeat != temp {
+		t.Errorf("heat reached R outside the network: Output %v W, %v J pending, %v°C", f.Output, r.PendingHeat, r.CurrentHeat)
+	}
+	if err := s.Audit.Err(); err != nil {
+		t.Error(err)
+	}
+	if got := kinds(t, s.Validate()); got[game.UnheatedTarget] != 1 {
+		t.Errorf("Validate() found %v, want an unheated target", got)
+	}
+}
+
+func TestFurnace_RunsOutOfFuel(t *testing.T) {
+	r := newSink("R")
+	f := newFurnace(0.05, r)
+	if got := f.BurnTime(); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("BurnTime() = %v, want 0.5", got)
+	}
+	s := &game.System{Nodes: []game.Component{r}, Machines: []game.Component{f}}
+	s.StepN(10)
+	if f.Fuel() != 0 || f.Output != 0 {
+		t.Errorf("after running dry fuel = %v output = %v", f.Fuel(), f.Output)
+	}
+	if got := f.ClearAsh(); math.Abs(got-0.005) > 1e-9 || f.AmountOf(game.Ash) != 0 {
+		t.Errorf("ClearAsh() = %v, left %v", got, f.AmountOf(game.Ash))
+	}
+
+	f.Toggle()
+	if f.Firing != 0 || !math.IsInf(f.BurnTime(), 1) {
+		t.Errorf("put out furnace firing %v burn time %v", f.Firing, f.BurnTime())
+	}
+}
+
+func TestCoalPlant_BoilsWater(t *testing.T) {
+	hopper := newHopper("H", 100)
+	b := &game.Boiler{Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 95, Stratified: true, DrawTop: true}}
+	b.AddMaterial(game.Water, 100)
+	f := newFurnace(0, b)
+	feeder := &game.Feeder{Source: hopper, Target: f, Rate: 0.1, On: true}
+	s := &game.System{
+		Nodes:    []game.Component{hopper, b},
+		Machines: []game.Component{feeder, f},
+	}
+
+	s.StepN(120)
+	if b.AmountOf(game.Steam) <= 0 {
+		t.Errorf("no steam after burning %v kg of coal, boiler at %v°C", 100-hopper.AmountOf(game.Coal)-f.Fuel(), b.CurrentHeat)
+	}
+}
+
+func TestLevel_Spawn_Furnace(t *testing.T) {
+	l := setupTestLevel(t)
+	ent, err := l.Spawn(game.EntityConfig{Type: "Furnace", X: 3, Y: 3, Identifier: "F", InitialQty: 2})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	f, ok := ent.Component.(*game.Furnace)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Furnace", ent.Component)
+	}
+	if f.Fuel() != 2 || f.Firing != 1 || f.MaxBurn != 0.1 || f.Efficiency != 0.8 {
+		t.Errorf("furnace defaults = fuel %v firing %v burn %v efficiency %v", f.Fuel(), f.Firing, f.MaxBurn, f.Efficiency)
+	}
+	if len(l.System.Machines) != 1 {
+		t.Errorf("furnace registered as %d machines, want 1", len(l.System.Machines))
+	}
+}
@@ -101,3 +101,25 @@ func TestGame_IsoToCartesian(t *testing.T) {
 func TestGame_Draw(t *testing.T) {
 	// No-op
 }
+
+func TestGame_ScreenToTile(t *testing.T) {
+	g, _ := game.NewGame()
+	g.Layout(800, 600)
+	// Tile (1,0) is drawn from iso (16,8); its centre is at (32,16), offset by the screen centre.
+	x, y := g.ScreenToTile(432, 316)
+	if x != 1 || y != 0 {
+		t.Errorf("ScreenToTile(432,316) = %d,%d, want 1,0", x, y)
+	}
+}
+
+func TestGame_Select(t *testing.T) {
+	g, _ := game.NewGame()
+	g.Select(0, 0) // Floor only
+	if g.Selected() != nil {
+		t.Error("Select() picked an entity on a floor-only tile")
+	}
+	g.Select(-5, -5)
+	if g.Selected() != nil {
+		t.Error("Select() picked an entity off the map")
+	}
+}
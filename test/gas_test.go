@@ -0,0 +1,81 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestGasPressure(t *testing.T) {
+	r := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 10, Area: 1, CurrentHeat: 100}}
+	r.AddMaterial(game.Water, 5000) // 5 m^3 of water
+	r.AddMaterial(game.Steam, 5)
+
+	// Steam fills the 5 m^3 above the water.
+	want := 5 * game.Steam.GasConstant * (100 + game.ZeroCelsius) / 5
+	if got := game.GasPressure(r); math.Abs(got-want) > 1e-6 {
+		t.Errorf("GasPressure() = %v, want %v", got, want)
+	}
+	if got := game.GasDensity(r); math.Abs(got-1) > 1e-9 {
+		t.Errorf("GasDensity() = %v, want 1", got)
+	}
+	if got := r.Volume(); math.Abs(got-5) > 1e-9 {
+		t.Errorf("Volume() = %v, want only the water's 5 m^3", got)
+	}
+
+	// Heating a closed vessel raises the pressure of its gas.
+	r.CurrentHeat = 200
+	if got := game.GasPressure(r); got <= want {
+		t.Errorf("GasPressure() at 200°C = %v, want above %v", got, want)
+	}
+
+	if got := game.GasPressure(&game.Reservoir{}); got != 0 {
+		t.Errorf("GasPressure() of an empty component = %v, want 0", got)
+	}
+}
+
+func TestGasDensity_AtmosphericSteam(t *testing.T) {
+	// Steam at its listed density and 100°C sits near 1 atm.
+	r := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 100}}
+	r.AddMaterial(game.Steam, 10*game.Steam.Density)
+	if p := game.GasPressure(r); math.Abs(p-game.AtmosphericPressure)/game.AtmosphericPressure > 0.05 {
+		t.Errorf("GasPressure() = %v, want about 1 atm", p)
+	}
+}
+
+func TestCalculateFlow_ChokedSteam(t *testing.T) {
+	src := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 1000, CurrentHeat: 200}}
+	src.AddMaterial(game.Steam, 10000)
+	dst := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 1000, CurrentHeat: 200}}
+
+	game.CalculateFlow(src, dst, 0)
+
+	// Direct connections flow through a 1 m^2 opening. The drop is large
+	// enough that the steam reaches the speed of sound in it.
+	g := game.Steam.HeatRatio
+	rt := game.Steam.GasConstant * (200 + game.ZeroCelsius)
+	choked := game.GasPressure(src) * math.Sqrt(g/rt) * math.Pow(2/(g+1), (g+1)/(2*(g-1)))
+	want := choked * game.TimeStep
+	if got := dst.PendingChange; math.Abs(got-want)/want > 1e-9 {
+		t.Errorf("moved %v kg, want the choked %v kg", got, want)
+	}
+	if src.PendingChange != -dst.PendingChange {
+		t.Errorf("mass not conserved: %v out, %v in", -src.PendingChange, dst.PendingChange)
+	}
+}
+
+func TestCalculateFlow_GasStopsAtEqualDensity(t *testing.T) {
+	src := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 100, CurrentHeat: 200}}
+	src.AddMaterial(game.Steam, 500) // 5 kg/m^3
+	dst := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 1, CurrentHeat: 200}}
+
+	game.CalculateFlow(src, dst, 0)
+	game.ApplyPending(src)
+	game.ApplyPending(dst)
+
+	// The small vessel fills up to the source's density and no further.
+	if got := game.GasDensity(dst); math.Abs(got-game.GasDensity(src)) > 0.1 {
+		t.Errorf("destination density = %v, want about the source's %v", got, game.GasDensity(src))
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func wireRow(g *game.Grid, y, from, to int) {
+	for x := from; x <= to; x++ {
+		g.AddWire(&game.Wire{X: x, Y: y})
+	}
+}
+
+func TestGrid_Balance(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       float64
+		demands      []float64
+		wantSupplied []float64
+		wantUnserved float64
+	}{
+		{
+			name:         "Surplus serves everyone",
+			output:       1000,
+			demands:      []float64{300, 200},
+			wantSupplied: []float64{300, 200},
+			wantUnserved: 0,
+		},
+		{
+			name:         "Shortage is shared proportionally",
+			output:       250,
+			demands:      []float64{300, 200},
+			wantSupplied: []float64{150, 100},
+			wantUnserved: 250,
+		},
+		{
+			name:         "No supply",
+			output:       0,
+			demands:      []float64{100},
+			wantSupplied: []float64{0},
+			wantUnserved: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g game.Grid
+			wireRow(&g, 0, 0, 5)
+			g.Attach(&game.Generator{Output: tt.output}, 0, 1) // Below the first wire
+			var sinks []*game.Consumer
+			for i, d := range tt.demands {
+				c := &game.Consumer{Demand: d}
+				sinks = append(sinks, c)
+				g.Attach(c, 5-i, 0)
+			}
+
+			g.Balance()
+
+			if len(g.Networks) != 1 {
+				t.Fatalf("Networks = %d, want 1", len(g.Networks))
+			}
+			for i, c := range sinks {
+				if math.Abs(c.Supplied-tt.wantSupplied[i]) > 1e-9 {
+					t.Errorf("sink %d Supplied = %v, want %v", i, c.Supplied, tt.wantSupplied[i])
+				}
+			}
+			if math.Abs(g.Unserved()-tt.wantUnserved) > 1e-9 {
+				t.Errorf("Unserved() = %v, want %v", g.Unserved(), tt.wantUnserved)
+			}
+		})
+	}
+}
+
+func TestGrid_SeparateNetworks(t *testing.T) {
+	var g game.Grid
+	wireRow(&g, 0, 0, 2)
+	wireRow(&g, 0, 4, 6) // Gap at x=3
+
+	g.Attach(&game.Generator{Output: 500}, 0, 0)
+	near := &game.Consumer{Demand: 100}
+	far := &game.Consumer{Demand: 100}
+	stray := &game.Consumer{Demand: 40}
+	g.Attach(near, 2, 0)
+	g.Attach(far, 6, 0)
+	g.Attach(stray, 10, 10)
+	g.Attach(&game.Reservoir{}, 1, 0) // Not electrical, ignored
+
+	g.Balance()
+
+	if len(g.Networks) != 3 {
+		t.Fatalf("Networks = %d, want 3", len(g.Networks))
+	}
+	if len(g.Devices) != 4 {
+		t.Errorf("Devices = %d, want 4", len(g.Devices))
+	}
+	if near.Supplied != 100 || far.Supplied != 0 || stray.Supplied != 0 {
+		t.Errorf("Supplied = near %v far %v stray %v, want 100 0 0", near.Supplied, far.Supplied, stray.Supplied)
+	}
+	if g.Unserved() != 140 || g.Supply() != 500 || g.Demand() != 240 {
+		t.Errorf("totals = supply %v demand %v unserved %v", g.Supply(), g.Demand(), g.Unserved())
+	}
+}
+
+func TestLevel_Spawn_Grid(t *testing.T) {
+	l := setupTestLevel(t)
+	nodes := len(l.System.Nodes)
+
+	for x := 0; x < 3; x++ {
+		if _, err := l.Spawn(game.EntityConfig{Type: "Wire", X: x, Y: 0}); err != nil {
+			t.Fatalf("Spawn wire failed: %v", err)
+		}
+	}
+	ent, err := l.Spawn(game.EntityConfig{Type: "Consumer", X: 3, Y: 0, Identifier: "TOWN", Demand: 1e3})
+	if err != nil {
+		t.Fatalf("Spawn consumer failed: %v", err)
+	}
+
+	if len(l.System.Grid.Wires) != 3 || len(l.System.Grid.Devices) != 1 {
+		t.Errorf("grid = %d wires %d devices, want 3 and 1", len(l.System.Grid.Wires), len(l.System.Grid.Devices))
+	}
+	if len(l.System.Nodes) != nodes {
+		t.Error("electrical components registered as hydraulic nodes")
+	}
+
+	l.System.StepN(1)
+	if got := l.System.Grid.Unserved(); got != 1e3 {
+		t.Errorf("Unserved() = %v, want 1000", got)
+	}
+	if ent.Component.(*game.Consumer).Supplied != 0 {
+		t.Error("consumer supplied with no generator")
+	}
+}
+
+func TestGrid_SharedTile(t *testing.T) {
+	var g game.Grid
+	first, second := &game.Wire{X: 2, Y: 2}, &game.Wire{X: 2, Y: 2}
+	g.AddWire(first)
+	g.AddWire(second)
+	g.Attach(&game.Generator{Output: 500}, 2, 3)
+	town := &game.Consumer{Demand: 400}
+	g.Attach(town, 1, 2)
+
+	g.Balance()
+
+	if len(g.Networks) != 1 || len(g.Networks[0].Wires) != 2 {
+		t.Fatalf("got %d networks, want one holding both wires", len(g.Networks))
+	}
+	if town.Supplied != 400 {
+		t.Errorf("Supplied = %v, want 400", town.Supplied)
+	}
+}
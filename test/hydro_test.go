@@ -0,0 +1,86 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestGenerator_EfficiencyAt(t *testing.T) {
+	g := &game.Generator{Efficiency: 0.9}
+	if got := g.EfficiencyAt(5); got != 0.9 {
+		t.Errorf("flat EfficiencyAt() = %v, want 0.9", got)
+	}
+
+	g.RatedFlow = 2
+	tests := []struct {
+		q, want float64
+	}{
+		{q: 2, want: 0.9},
+		{q: 1, want: 0.675},
+		{q: 0, want: 0},
+		{q: 5, want: 0},
+	}
+	for _, tt := range tests {
+		if got := g.EfficiencyAt(tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EfficiencyAt(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestOutfall_CarriesAway(t *testing.T) {
+	o := &game.Outfall{Structurals: game.Structurals{MaxVolume: 100, Area: 10, BaseElevation: -5}}
+	o.AddMaterial(game.Water, 300)
+	o.Update(nil, game.TimeStep)
+	if o.Quantity != 0 || o.Discharged != 300 {
+		t.Errorf("outfall holds %v, discharged %v, want 0 and 300", o.Quantity, o.Discharged)
+	}
+	if game.TotalHead(o) != -5 {
+		t.Errorf("TotalHead() = %v, want -5", game.TotalHead(o))
+	}
+}
+
+func TestHydroDam(t *testing.T) {
+	l := setupTestLevel(t)
+	spawn := func(cfg game.EntityConfig) game.Component {
+		ent, err := l.Spawn(cfg)
+		if err != nil {
+			t.Fatalf("Spawn %s failed: %v", cfg.Type, err)
+		}
+		return ent.Component
+	}
+	dam := spawn(game.EntityConfig{Type: "Reservoir", X: 0, Y: 0, Identifier: "DAM", MaxVolume: 2000, InitialQty: 1e6, Area: 100, Elevation: 50})
+	penstock := spawn(game.EntityConfig{Type: "Pipe", X: 0, Y: 1, Identifier: "PEN", PipeLength: 60, PipeRadius: 0.3}).(*game.Pipe)
+	turbine := spawn(game.EntityConfig{Type: "WaterTurbine", X: 0, Y: 2, Identifier: "T", RatedFlow: 0.5}).(*game.Generator)
+	tailrace := spawn(game.EntityConfig{Type: "Pipe", X: 0, Y: 3, Identifier: "TAIL", PipeLength: 5, PipeRadius: 0.3}).(*game.Pipe)
+	river := spawn(game.EntityConfig{Type: "Outfall", X: 1, Y: 3, Identifier: "RIV", Elevation: -5}).(*game.Outfall)
+	penstock.From, penstock.To = dam, turbine
+	tailrace.From, tailrace.To = turbine, river
+
+	if dam.GetStructurals().BaseElevation != 50 {
+		t.Fatalf("dam elevation = %v, want 50", dam.GetStructurals().BaseElevation)
+	}
+	if turbine.RatedFlow != 0.5 || turbine.Efficiency != 0.93 {
+		t.Errorf("turbine = rated %v efficiency %v", turbine.RatedFlow, turbine.Efficiency)
+	}
+
+	l.System.StepN(300)
+	if turbine.Output <= 0 || turbine.Discharge <= 0 {
+		t.Fatalf("turbine output %v W at %v m^3/s, want power", turbine.Output, turbine.Discharge)
+	}
+	if river.Discharged <= 0 {
+		t.Error("nothing left through the tailrace")
+	}
+
+	// Output can never beat the water falling the full height at peak
+	// efficiency.
+	fall := game.TotalHead(dam) - river.BaseElevation
+	limit := 0.93 * turbine.Discharge * game.Water.Density * game.Gravity * fall
+	if turbine.Output > limit {
+		t.Errorf("Output %v W is more than the %v W available", turbine.Output, limit)
+	}
+	if l.System.Grid.Supply() != turbine.Output {
+		t.Errorf("grid supply = %v, want the turbine's %v", l.System.Grid.Supply(), turbine.Output)
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func newJunction(id string) *game.Junction {
+	return &game.Junction{
+		Basics:      game.Basics{Identifier: id},
+		Structurals: game.Structurals{IsJunction: true, Contents: []game.MaterialDef{game.Water}},
+	}
+}
+
+func TestJunction_Splits(t *testing.T) {
+	src, left, right := newSink("S"), newSink("L"), newSink("R")
+	src.BaseElevation = 10
+	src.AddMaterial(game.Water, 5000)
+	j := newJunction("J")
+	s := &game.System{
+		Nodes: []game.Component{src, j, left, right},
+		Pipes: []*game.Pipe{
+			game.NewPipe(src, j, 2, 0.3),
+			game.NewPipe(j, left, 2, 0.3),
+			game.NewPipe(j, right, 2, 0.3),
+		},
+	}
+
+	s.StepN(60)
+	if left.Quantity <= 0 || right.Quantity <= 0 {
+		t.Fatalf("tee fed left %v and right %v, want both", left.Quantity, right.Quantity)
+	}
+	if math.Abs(left.Quantity-right.Quantity) > 1e-6*left.Quantity {
+		t.Errorf("matching branches got %v and %v", left.Quantity, right.Quantity)
+	}
+	if j.Quantity > 1e-9 {
+		t.Errorf("junction holds %v, want nothing", j.Quantity)
+	}
+	if got := totalQuantity(s); math.Abs(got-5000) > 1e-6 {
+		t.Errorf("total = %v, want 5000", got)
+	}
+}
+
+func TestJunction_MergesMixAndHeat(t *testing.T) {
+	oil := game.MaterialDef{ID: "test_oil", Type: game.TypeFluid, Density: 1000, SpecificHeat: 2000}
+	hot, cold, dst := newSink("H"), newSink("C"), newSink("D")
+	hot.BaseElevation, cold.BaseElevation = 10, 10
+	hot.CurrentHeat, cold.CurrentHeat, dst.CurrentHeat = 80, 20, 20
+	hot.AddMaterial(game.Water, 1000)
+	cold.Contents = nil
+	cold.AddMaterial(oil, 1000)
+	j := newJunction("J")
+	s := &game.System{
+		Nodes: []game.Component{hot, cold, j, dst},
+		Pipes: []*game.Pipe{
+			game.NewPipe(hot, j, 2, 0.3),
+			game.NewPipe(cold, j, 2, 0.3),
+			game.NewPipe(j, dst, 2, 0.3),
+		},
+	}
+
+	energy := func() float64 {
+		e := 0.0
+		for _, c := range s.Network() {
+			st := c.GetStructurals()
+			e += game.HeatCapacity(st) * st.CurrentHeat
+		}
+		return e
+	}
+	before := energy()
+	s.Solve(game.TimeStep)
+	for _, c := range s.Network() {
+		game.ApplyPending(c)
+	}
+	if after := energy(); math.Abs(after-before)/before > 1e-9 {
+		t.Errorf("energy not balanced: before=%v after=%v", before, after)
+	}
+
+	s.StepN(60)
+	if dst.AmountOf(game.Water) <= 0 || dst.AmountOf(oil) <= 0 {
+		t.Fatalf("manifold delivered water %v and oil %v, want both", dst.AmountOf(game.Water), dst.AmountOf(oil))
+	}
+	if j.Quantity > 1e-9 {
+		t.Errorf("junction holds %v, want nothing", j.Quantity)
+	}
+}
+
+func TestJunction_DoesNotThrottle(t *testing.T) {
+	// Two pipes meeting at a junction should carry about what they carry
+	// joined end to end, while a tiny tank in the same spot chokes the run.
+	run := func(middle game.Component) float64 {
+		src, dst := newSink("S"), newSink("D")
+		src.BaseElevation = 10
+		src.AddMaterial(game.Water, 5000)
+		in := game.NewPipe(src, middle, 1, 0.3)
+		if middle == nil {
+			middle = in
+		}
+		out := game.NewPipe(middle, dst, 1, 0.3)
+		if in.To == nil {
+			in.To = out
+		}
+		s := &game.System{Nodes: []game.Component{src, dst}, Pipes: []*game.Pipe{in, out}}
+		s.StepN(30)
+		return dst.Quantity
+	}
+	tiny := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 0.001, Area: 0.001}}
+	plain, joined, choked := run(nil), run(newJunction("J")), run(tiny)
+	if joined < 0.5*plain {
+		t.Errorf("junction run delivered %v, end to end %v", joined, plain)
+	}
+	if joined < 5*choked {
+		t.Errorf("junction run delivered %v, tiny tank %v", joined, choked)
+	}
+}
+
+func TestLevel_Spawn_Junction(t *testing.T) {
+	l := setupTestLevel(t)
+	ent, err := l.Spawn(game.EntityConfig{Type: "Junction", X: 2, Y: 2, Identifier: "J"})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	j, ok := ent.Component.(*game.Junction)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Junction", ent.Component)
+	}
+	if !j.IsJunction {
+		t.Error("spawned junction is not marked IsJunction")
+	}
+}
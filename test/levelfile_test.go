@@ -0,0 +1,164 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// writeLevel writes body to a level file in a fresh directory.
+func writeLevel(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "level.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLevel_Default(t *testing.T) {
+	l, err := game.LoadLevel(game.DefaultLevel)
+	if err != nil {
+		t.Fatalf("LoadLevel() error = %v", err)
+	}
+	if w, h := l.Size(); w != 4 || h != 4 {
+		t.Errorf("size = %dx%d, want 4x4", w, h)
+	}
+	if len(l.System.Nodes) != 2 || len(l.System.Pipes) != 1 {
+		t.Fatalf("system has %d nodes %d pipes, want 2 and 1", len(l.System.Nodes), len(l.System.Pipes))
+	}
+	p := l.System.Pipes[0]
+	if game.Identifier(p.From) != "A" || game.Identifier(p.To) != "B" {
+		t.Errorf("P1 runs %s -> %s, want A -> B", game.Identifier(p.From), game.Identifier(p.To))
+	}
+	if a := p.From.GetStructurals(); a.Quantity != 2000 || a.Contents[0].ID != "water" {
+		t.Errorf("A holds %v of %v", a.Quantity, a.Contents)
+	}
+
+	// Validated once the level is laid out, and again on each Spawn after.
+	if err := l.Issues(); err != nil {
+		t.Errorf("Issues() = %v, want nil", err)
+	}
+	if _, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 3, Y: 3, Identifier: "LONE"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(t, l.Issues()); got[game.IsolatedNetwork] != 1 {
+		t.Errorf("Issues() after Spawn found %v, want an isolated network", got)
+	}
+}
+
+func TestLoadLevel_Floor(t *testing.T) {
+	l, err := game.LoadLevel(writeLevel(t, `{
+		"Width": 3, "Height": 2,
+		"Floor": ["##.", ".##"]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadLevel() error = %v", err)
+	}
+	if got := len(l.Entities()); got != 4 {
+		t.Errorf("%d floor tiles, want 4", got)
+	}
+	if len(l.Tile(2, 0).Entities()) != 0 {
+		t.Error("bare tile got a floor")
+	}
+}
+
+func TestLoadLevel_ReportsEveryError(t *testing.T) {
+	_, err := game.LoadLevel(writeLevel(t, `{
+		"Width": 2, "Height": 2,
+		"Entities": [
+			{"Type": "Reservoir", "X": 0, "Y": 0, "Identifier": "A"},
+			{"Type": "Reservoir", "X": 5, "Y": 0, "Identifier": "FAR"},
+			{"Type": "Teleporter", "X": 1, "Y": 1},
+			{"Type": "Pipe", "X": 0, "Y": 1, "Identifier": "P1"},
+			{"Type": "Pump", "X": 1, "Y": 0, "Identifier": "PU"}
+		],
+		"Connections": [
+			{"Pipe": "P1", "From": "A", "To": "NOWHERE"},
+			{"Pipe": "A", "From": "A", "To": "A"},
+			{"Pipe": "P1", "From": "A", "To": "PU"}
+		]
+	}`))
+	if err == nil {
+		t.Fatal("LoadLevel() error = nil")
+	}
+	for _, want := range []string{
+		`"FAR"): tile 5,0 is outside`,
+		`Teleporter "")`,
+		`no component named "NOWHERE"`,
+		`"A" is a *game.Reservoir, not a pipe`,
+		`connection 2 (P1): a pipe cannot run to or from PU, a *game.Pump`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadLevel_EntityReferences(t *testing.T) {
+	l, err := game.LoadLevel(writeLevel(t, `{
+		"Width": 3, "Height": 1,
+		"Entities": [
+			{"Type": "Reservoir", "X": 0, "Y": 0, "Identifier": "A"},
+			{"Type": "Reservoir", "X": 2, "Y": 0, "Identifier": "B"},
+			{"Type": "Pipe", "X": 1, "Y": 0, "Identifier": "P1", "From": "A", "To": "B"},
+			{"Type": "Pipe", "X": 1, "Y": 0, "Identifier": "P2", "From": "B", "To": "LATER"}
+		]
+	}`))
+	if err == nil || !strings.Contains(err.Error(), `entity 3 (Pipe "P2"): no component named "LATER"`) {
+		t.Fatalf("LoadLevel() error = %v, want P2's forward reference reported", err)
+	}
+
+	l, err = game.LoadLevel(writeLevel(t, `{
+		"Width": 3, "Height": 1,
+		"Entities": [
+			{"Type": "Reservoir", "X": 0, "Y": 0, "Identifier": "A"},
+			{"Type": "Reservoir", "X": 2, "Y": 0, "Identifier": "B"},
+			{"Type": "Pipe", "X": 1, "Y": 0, "Identifier": "P1", "From": "A", "To": "B"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadLevel() error = %v", err)
+	}
+	p := l.System.Pipes[0]
+	if game.Identifier(p.From) != "A" || game.Identifier(p.To) != "B" {
+		t.Errorf("P1 runs %s -> %s, want A -> B", game.Identifier(p.From), game.Identifier(p.To))
+	}
+}
+
+func TestLoadLevel_BadFile(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown field":    `{"Width": 1, "Height": 1, "Wdith": 2}`,
+		"unknown material": `{"Width": 1, "Height": 1, "Entities": [{"Type": "Reservoir", "Contents": "lava"}]}`,
+		"no size":          `{}`,
+		"short floor":      `{"Width": 2, "Height": 1, "Floor": ["#"]}`,
+		"dangling pipe":    `{"Width": 1, "Height": 1, "Entities": [{"Type": "Pipe"}]}`,
+	} {
+		if _, err := game.LoadLevel(writeLevel(t, body)); err == nil {
+			t.Errorf("%s: LoadLevel() error = nil", name)
+		}
+	}
+}
+
+func TestEntityConfig_JSON(t *testing.T) {
+	half := 0.5
+	in := game.EntityConfig{Type: "Valve", Identifier: "V", Contents: &game.Steam, Opening: &half}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Contents":"steam"`) {
+		t.Errorf("Contents not written by ID: %s", data)
+	}
+	var out game.EntityConfig
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Contents != &game.Steam || *out.Opening != 0.5 || out.Identifier != "V" {
+		t.Errorf("round trip = %+v", out)
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestSetLogging_Trace(t *testing.T) {
+	defer game.SetLogging(os.Stderr, slog.LevelWarn)
+
+	a, b := newSink("A"), newSink("B")
+	a.AddMaterial(game.Water, 1000)
+	p := game.NewPipe(a, b, 10, 0.5)
+	p.Identifier = "P1"
+	s := &game.System{Nodes: []game.Component{a, b}, Pipes: []*game.Pipe{p}}
+
+	var buf bytes.Buffer
+	game.SetLogging(&buf, game.LevelTrace, "A", "P1")
+	s.StepN(2)
+	out := buf.String()
+	for _, want := range []string{"level=TRACE", "id=A", "id=P1", "level=DEBUG msg=step"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "id=B") {
+		t.Errorf("log traced B, which was not asked for:\n%s", out)
+	}
+
+	buf.Reset()
+	game.SetLogging(&buf, game.LevelTrace)
+	s.StepN(1)
+	if !strings.Contains(buf.String(), "id=B") {
+		t.Error("tracing with no filter left out B")
+	}
+}
+
+func TestSetLogging_Quiet(t *testing.T) {
+	defer game.SetLogging(os.Stderr, slog.LevelWarn)
+
+	a, b := newSink("A"), newSink("B")
+	a.AddMaterial(game.Water, 1000)
+	s := &game.System{Nodes: []game.Component{a, b}, Pipes: []*game.Pipe{game.NewPipe(a, b, 10, 0.5)}}
+
+	var buf bytes.Buffer
+	game.SetLogging(&buf, slog.LevelWarn)
+	s.StepN(10)
+	if buf.Len() > 0 {
+		t.Errorf("normal play logged:\n%s", buf.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"trace": game.LevelTrace,
+		"TRACE": game.LevelTrace,
+		"debug": slog.LevelDebug,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		if got, err := game.ParseLogLevel(name); err != nil || got != want {
+			t.Errorf("ParseLogLevel(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := game.ParseLogLevel("loud"); err == nil {
+		t.Error("ParseLogLevel(\"loud\") did not fail")
+	}
+}
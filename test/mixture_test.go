@@ -0,0 +1,139 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestStructurals_AddMaterial(t *testing.T) {
+	s := &game.Structurals{Quantity: 100, Contents: []game.MaterialDef{game.Water}}
+
+	s.AddMaterial(game.Steam, 300)
+	if s.Quantity != 400 {
+		t.Errorf("Quantity = %v, want 400", s.Quantity)
+	}
+	if got := s.AmountOf(game.Water); got != 100 {
+		t.Errorf("AmountOf(Water) = %v, want 100", got)
+	}
+	if s.Contents[0].ID != game.Steam.ID {
+		t.Errorf("dominant material = %v, want steam", s.Contents[0].ID)
+	}
+
+	s.AddMaterial(game.Steam, -1000)
+	if got := s.AmountOf(game.Steam); got != 0 {
+		t.Errorf("AmountOf(Steam) after over-draw = %v, want 0", got)
+	}
+	if s.Quantity != 100 || len(s.Contents) != 1 {
+		t.Errorf("after removing steam: qty=%v contents=%d, want 100 and 1", s.Quantity, len(s.Contents))
+	}
+}
+
+func TestStructurals_AmountOf_Legacy(t *testing.T) {
+	// Components built without Amounts hold all of Quantity as Contents[0].
+	s := &game.Structurals{Quantity: 50, Contents: []game.MaterialDef{game.Coal}}
+	if got := s.AmountOf(game.Coal); got != 50 {
+		t.Errorf("AmountOf(Coal) = %v, want 50", got)
+	}
+	if got := s.AmountOf(game.Water); got != 0 {
+		t.Errorf("AmountOf(Water) = %v, want 0", got)
+	}
+}
+
+func TestApplyPending_PerMaterial(t *testing.T) {
+	r := &game.Reservoir{Structurals: game.Structurals{Quantity: 10, Contents: []game.MaterialDef{game.Water}}}
+	r.QueueMaterial(game.Steam, 4)
+	r.QueueMaterial(game.Water, -2)
+	game.ApplyPending(r)
+
+	if r.Quantity != 12 {
+		t.Errorf("Quantity = %v, want 12", r.Quantity)
+	}
+	if r.AmountOf(game.Water) != 8 || r.AmountOf(game.Steam) != 4 {
+		t.Errorf("amounts = water %v steam %v, want 8 and 4", r.AmountOf(game.Water), r.AmountOf(game.Steam))
+	}
+	if r.PendingChange != 0 || r.PendingAmounts != nil {
+		t.Error("pending state not cleared")
+	}
+}
+
+func TestTotalHead_Mixture(t *testing.T) {
+	gas := game.MaterialDef{ID: "test_gas", Type: game.TypeGas, Density: 1, GasConstant: 100}
+	r := &game.Reservoir{Structurals: game.Structurals{Area: 1, MaxVolume: 10}}
+	r.AddMaterial(game.Water, 5000) // 5 m^3 of water, 5 m of head
+	r.AddMaterial(gas, 50)
+
+	// Gas fills the 5 m^3 left above the water at 0°C: P = 50 * 100 * 273.15 / 5,
+	// and heads are gauge, so only the part above atmospheric counts.
+	want := 5.0 + (50*100*273.15/5-game.AtmosphericPressure)/(game.Water.Density*game.Gravity)
+	if got := game.TotalHead(r); math.Abs(got-want) > 1e-9 {
+		t.Errorf("TotalHead() = %v, want %v", got, want)
+	}
+}
+
+func TestSystem_Tick_MixesFlows(t *testing.T) {
+	oil := game.MaterialDef{ID: "test_oil", Name: "Oil", Type: game.TypeFluid, Density: 800}
+	a, b, mix := newSink("A"), newSink("B"), newSink("M")
+	a.BaseElevation, b.BaseElevation = 10, 10
+	a.AddMaterial(game.Water, 2000)
+	b.Contents = nil
+	b.AddMaterial(oil, 2000)
+
+	s := &game.System{
+		Nodes: []game.Component{a, b, mix},
+		Pipes: []*game.Pipe{game.NewPipe(a, mix, 2, 0.5), game.NewPipe(b, mix, 2, 0.5)},
+	}
+	s.StepN(50)
+
+	water, oilMass := mix.AmountOf(game.Water), mix.AmountOf(oil)
+	if water <= 0 || oilMass <= 0 {
+		t.Fatalf("tank did not receive both fluids: water=%v oil=%v", water, oilMass)
+	}
+	if math.Abs(mix.Quantity-(water+oilMass)) > 1e-9 {
+		t.Errorf("Quantity %v != sum of amounts %v", mix.Quantity, water+oilMass)
+	}
+
+	totalWater, totalOil := 0.0, 0.0
+	for _, c := range s.Network() {
+		totalWater += c.GetStructurals().AmountOf(game.Water)
+		totalOil += c.GetStructurals().AmountOf(oil)
+	}
+	if math.Abs(totalWater-2000) > 1e-6 || math.Abs(totalOil-2000) > 1e-6 {
+		t.Errorf("per-material mass not conserved: water=%v oil=%v", totalWater, totalOil)
+	}
+}
+
+func TestSystem_Solve_OutflowComposition(t *testing.T) {
+	oil := game.MaterialDef{ID: "test_oil", Name: "Oil", Type: game.TypeFluid, Density: 800}
+	tests := []struct {
+		name       string
+		stratified bool
+		wantOil    bool
+	}{
+		{name: "Well mixed gives a share of each", stratified: false, wantOil: true},
+		{name: "Stratified gives the densest first", stratified: true, wantOil: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := newSink("S"), newSink("D")
+			src.BaseElevation = 10
+			src.Stratified = tt.stratified
+			src.AddMaterial(game.Water, 500)
+			src.AddMaterial(oil, 500)
+
+			p := game.NewPipe(src, dst, 2, 0.1)
+			s := &game.System{Nodes: []game.Component{src, dst}, Pipes: []*game.Pipe{p}}
+			s.Solve(game.TimeStep)
+			game.ApplyPending(src)
+			game.ApplyPending(p)
+
+			if got := p.AmountOf(oil) > 0; got != tt.wantOil {
+				t.Errorf("pipe received oil = %v, want %v", got, tt.wantOil)
+			}
+			if p.AmountOf(game.Water) <= 0 {
+				t.Error("pipe received no water")
+			}
+		})
+	}
+}
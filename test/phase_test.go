@@ -0,0 +1,104 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestBoilingPoint(t *testing.T) {
+	if got := game.BoilingPoint(game.AtmosphericPressure); math.Abs(got-100) > 0.1 {
+		t.Errorf("BoilingPoint(1 atm) = %v, want ~100", got)
+	}
+	if got := game.BoilingPoint(10 * game.AtmosphericPressure); got < 170 || got > 190 {
+		t.Errorf("BoilingPoint(10 atm) = %v, want ~180", got)
+	}
+	if got := game.BoilingPoint(0); got != 0.01 {
+		t.Errorf("BoilingPoint(0) = %v, want 0.01", got)
+	}
+	if got := game.BoilingPoint(1e9); got > 374 {
+		t.Errorf("BoilingPoint(1 GPa) = %v, want clamped to critical point", got)
+	}
+}
+
+func TestPressure(t *testing.T) {
+	r := &game.Reservoir{Structurals: game.Structurals{Area: 1, BaseElevation: 50}}
+	r.AddMaterial(game.Water, 1000) // 1 m of water above the bottom
+	want := game.AtmosphericPressure + game.Water.Density*game.Gravity
+	if got := game.Pressure(r); math.Abs(got-want) > 1e-6 {
+		t.Errorf("Pressure() = %v, want %v", got, want)
+	}
+	if got := game.Pressure(nil); got != game.AtmosphericPressure {
+		t.Errorf("Pressure(nil) = %v, want atmospheric", got)
+	}
+}
+
+// thermalEnergy is sensible heat plus the latent heat held by steam.
+func thermalEnergy(s *game.Structurals) float64 {
+	return game.HeatCapacity(s)*s.CurrentHeat + s.AmountOf(game.Steam)*game.Water.LatentHeat
+}
+
+func TestBoiler_MakesSteam(t *testing.T) {
+	// No Area means no surface to lose heat through, so every joule stays.
+	b := &game.Boiler{
+		Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 20, Stratified: true, DrawTop: true},
+		HeatInput:   1e6,
+	}
+	b.AddMaterial(game.Water, 100)
+	s := &game.System{Nodes: []game.Component{b}}
+
+	before := thermalEnergy(&b.Structurals)
+	s.StepN(600)
+	added := b.HeatInput * s.Clock.Time
+
+	if b.AmountOf(game.Steam) <= 0 {
+		t.Fatalf("no steam produced, temperature %v", b.CurrentHeat)
+	}
+	if math.Abs(b.Quantity-100) > 1e-9 {
+		t.Errorf("mass changed during boiling: %v", b.Quantity)
+	}
+	if tb := game.BoilingPoint(game.Pressure(b)); math.Abs(b.CurrentHeat-tb) > 0.01 {
+		t.Errorf("boiling contents at %v°C, want boiling point %v°C", b.CurrentHeat, tb)
+	}
+	if after := thermalEnergy(&b.Structurals); math.Abs(after-(before+added))/after > 1e-9 {
+		t.Errorf("energy not balanced: before=%v added=%v after=%v", before, added, after)
+	}
+}
+
+func TestSystem_Step_Condenses(t *testing.T) {
+	r := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 20}}
+	r.AddMaterial(game.Steam, 1)
+	s := &game.System{Nodes: []game.Component{r}}
+
+	before := thermalEnergy(&r.Structurals)
+	s.StepN(1)
+
+	if r.AmountOf(game.Water) <= 0 {
+		t.Fatal("cold steam did not condense")
+	}
+	if r.CurrentHeat <= 20 {
+		t.Errorf("latent heat not released: %v°C", r.CurrentHeat)
+	}
+	if after := thermalEnergy(&r.Structurals); math.Abs(after-before)/before > 1e-9 {
+		t.Errorf("energy not balanced: before=%v after=%v", before, after)
+	}
+}
+
+func TestBoiler_DrawsSteamFromTop(t *testing.T) {
+	b := &game.Boiler{Structurals: game.Structurals{MaxVolume: 10, BaseElevation: 10, CurrentHeat: 150, Stratified: true, DrawTop: true}}
+	b.AddMaterial(game.Water, 500)
+	b.AddMaterial(game.Steam, 5)
+	dst := newSink("D")
+	p := game.NewPipe(b, dst, 2, 0.1)
+	s := &game.System{Nodes: []game.Component{b, dst}, Pipes: []*game.Pipe{p}}
+
+	s.Solve(game.TimeStep)
+	game.ApplyPending(p)
+	if p.AmountOf(game.Steam) <= 0 {
+		t.Error("boiler outlet carried no steam")
+	}
+	if p.AmountOf(game.Water) > 0 && p.AmountOf(game.Steam) < 5 {
+		t.Error("boiler let water out before its steam was gone")
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// newCore returns a 1 MW core cooled by a tonne of water, critical with
+// its rods half in.
+func newCore() *game.Reactor {
+	r := &game.Reactor{
+		Basics:      game.Basics{Identifier: "N"},
+		Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: 20},
+		RatedPower:  1e6,
+		Rods:        0.5,
+		RodWorth:    0.02,
+		Excess:      0.01,
+		RefTemp:     20,
+	}
+	r.AddMaterial(game.Water, 1000)
+	return r
+}
+
+func TestReactor_SteadyState(t *testing.T) {
+	r := newCore()
+	r.SetPower(1)
+	s := &game.System{Nodes: []game.Component{r}}
+
+	s.StepN(60)
+	if math.Abs(r.Neutrons-1) > 1e-3 {
+		t.Errorf("critical core drifted to %v", r.Neutrons)
+	}
+	if math.Abs(r.Output-1e6)/1e6 > 1e-3 {
+		t.Errorf("Output = %v W, want 1 MW", r.Output)
+	}
+}
+
+func TestReactor_PromptJump(t *testing.T) {
+	r := newCore()
+	r.SetPower(1)
+	r.SetRods(0.45) // +0.001, well below the delayed fraction
+	s := &game.System{Nodes: []game.Component{r}}
+
+	s.StepN(1)
+	beta := game.DelayedFraction()
+	jump := beta / (beta - 0.001)
+	if r.Neutrons < 1.1 || r.Neutrons > jump*1.05 {
+		t.Errorf("after the prompt jump n = %v, want about %v", r.Neutrons, jump)
+	}
+	before := r.Neutrons
+	s.StepN(60)
+	if r.Neutrons <= before {
+		t.Errorf("supercritical core did not keep rising: %v -> %v", before, r.Neutrons)
+	}
+}
+
+func TestReactor_ScramLeavesDecayHeat(t *testing.T) {
+	r := newCore()
+	r.SetPower(1)
+	s := &game.System{Nodes: []game.Component{r}}
+
+	r.Scram()
+	s.StepN(1)
+	if r.Neutrons > 0.5 {
+		t.Errorf("n = %v after scram, want a prompt drop", r.Neutrons)
+	}
+	s.StepN(1800) // 5 minutes
+	if r.FissionPower() > 1e3 {
+		t.Errorf("fission power %v W five minutes after scram", r.FissionPower())
+	}
+	if share := r.DecayPower() / r.RatedPower; share < 0.005 || share > 0.06 {
+		t.Errorf("decay heat = %v of rated five minutes after scram, want 0.5-6%%", share)
+	}
+	if r.Output <= 0 {
+		t.Error("scrammed core gave the coolant no heat")
+	}
+}
+
+func TestReactor_TemperatureFeedback(t *testing.T) {
+	r := newCore()
+	r.SetRods(0.25) // +0.005
+	r.TempCoeff = -1e-3
+	r.SetPower(0.1)
+	s := &game.System{Nodes: []game.Component{r}}
+
+	s.StepN(1200)
+	if r.CurrentHeat <= 20 {
+		t.Fatal("coolant did not warm up")
+	}
+	if rho := r.Reactivity(); rho > 0.001 {
+		t.Errorf("reactivity still %v after warming to %v°C", rho, r.CurrentHeat)
+	}
+}
+
+func TestReactor_OverheatScrams(t *testing.T) {
+	r := newCore()
+	r.SetPower(1)
+	r.MaxHeat = 21
+	s := &game.System{Nodes: []game.Component{r}}
+
+	s.StepN(60)
+	if !r.Scrammed || r.Rods != 1 {
+		t.Errorf("overheated core at %v°C not scrammed: rods %v", r.CurrentHeat, r.Rods)
+	}
+	r.SetRods(0.9)
+	if r.Scrammed {
+		t.Error("moving the rods did not clear the scram")
+	}
+}
+
+func TestReactor_HeatsCoolantLoop(t *testing.T) {
+	l := setupTestLevel(t)
+	half := 0.5
+	ent, err := l.Spawn(game.EntityConfig{
+		Type: "Reactor",
+		X:    0, Y: 0,
+		Identifier: "N",
+		InitialQty: 1000,
+		MaxVolume:  10,
+		Rods:       &half,
+	})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	r, ok := ent.Component.(*game.Reactor)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Reactor", ent.Component)
+	}
+	if r.RatedPower != 1e7 || r.Rods != 0.5 {
+		t.Errorf("reactor = rated %v rods %v", r.RatedPower, r.Rods)
+	}
+	r.BaseElevation = 5
+	r.SetRods(1 - r.Excess/r.RodWorth) // Critical
+	r.SetPower(1)
+
+	sink := newSink("HX")
+	sink.CurrentHeat = 20
+	s := &game.System{
+		Nodes: []game.Component{r, sink},
+		Pipes: []*game.Pipe{game.NewPipe(r, sink, 2, 0.1)},
+	}
+	s.StepN(60)
+	if sink.Quantity <= 0 || sink.CurrentHeat <= 20 {
+		t.Errorf("loop delivered %v kg at %v°C, want hot coolant", sink.Quantity, sink.CurrentHeat)
+	}
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// busyLevel returns the default level with a pump, a valve, a coal line
+// into a furnace heating A, and a burst tank, run for a few seconds.
+func busyLevel(t *testing.T) *game.Level {
+	t.Helper()
+	l := setupTestLevel(t)
+	spawn := func(c game.EntityConfig) game.Component {
+		t.Helper()
+		ent, err := l.Spawn(c)
+		if err != nil || ent == nil {
+			t.Fatalf("Spawn(%s) = %v, %v", c.Type, ent, err)
+		}
+		return ent.Component
+	}
+	p1 := l.System.Pipes[0]
+
+	spawn(game.EntityConfig{Type: "Pump", X: 2, Y: 2, Identifier: "PU"}).(*game.Pump).Pipe = p1
+	spawn(game.EntityConfig{Type: "Valve", X: 1, Y: 2, Identifier: "V"}).(*game.Valve).Fit(p1)
+	p1.Valve.SetOpening(0.4)
+
+	spawn(game.EntityConfig{Type: "Hopper", X: 3, Y: 0, Identifier: "H", InitialQty: 50})
+	spawn(game.EntityConfig{Type: "Furnace", X: 2, Y: 1, Identifier: "F", InitialQty: 2, To: "A"})
+	spawn(game.EntityConfig{Type: "Conveyor", X: 3, Y: 1, Path: [][2]int{{3, 2}}, From: "H", To: "F"})
+	spawn(game.EntityConfig{Type: "Feeder", X: 3, Y: 3, From: "H", To: "F"})
+
+	tank := spawn(game.EntityConfig{Type: "Reservoir", X: 0, Y: 0, Identifier: "T", InitialQty: 100})
+	tank.GetStructurals().Condition = game.Burst
+
+	l.System.StepN(30)
+	l.CollectLeaks()
+	l.System.StepN(5) // Leave some leaks uncollected
+	return l
+}
+
+func roundTrip(t *testing.T, l *game.Level) *game.Level {
+	t.Helper()
+	f, err := l.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := game.WriteSave(&buf, f); err != nil {
+		t.Fatalf("WriteSave() error = %v", err)
+	}
+	f, err = game.ReadSave(&buf)
+	if err != nil {
+		t.Fatalf("ReadSave() error = %v", err)
+	}
+	restored, err := game.Restore(f)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	return restored
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	l := busyLevel(t)
+	r := roundTrip(t, l)
+
+	if r.System.Clock != l.System.Clock || r.System.Ticks != l.System.Ticks {
+		t.Errorf("clock = %+v, want %+v", r.System.Clock, l.System.Clock)
+	}
+	if len(r.Entities()) != len(l.Entities()) {
+		t.Errorf("%d entities, want %d", len(r.Entities()), len(l.Entities()))
+	}
+	if len(r.System.Leaks) == 0 || len(r.System.Leaks) != len(l.System.Leaks) {
+		t.Errorf("%d leaks, want %d", len(r.System.Leaks), len(l.System.Leaks))
+	}
+	if got, want := r.Tile(0, 0).Spill.Quantity, l.Tile(0, 0).Spill.Quantity; got != want || got == 0 {
+		t.Errorf("spill = %v, want %v", got, want)
+	}
+
+	p := r.System.Pipes[0]
+	if game.Identifier(p.From) != "A" || game.Identifier(p.To) != "B" || p.Valve == nil || p.Valve.Opening != 0.4 {
+		t.Errorf("P1 restored as %s -> %s with valve %v", game.Identifier(p.From), game.Identifier(p.To), p.Valve)
+	}
+	for _, e := range r.Entities() {
+		if v, ok := e.Component.(*game.Valve); ok && v != p.Valve {
+			t.Error("P1 valve is not the restored valve entity")
+		}
+	}
+
+	// Both run on exactly alike from here.
+	l.System.StepN(30)
+	r.System.StepN(30)
+	want, got := l.System.Network(), r.System.Network()
+	if len(got) != len(want) {
+		t.Fatalf("%d components in the network, want %d", len(got), len(want))
+	}
+	for i := range want {
+		ws, gs := want[i].GetStructurals(), got[i].GetStructurals()
+		if gs.Quantity != ws.Quantity || gs.CurrentHeat != ws.CurrentHeat || gs.Damage != ws.Damage {
+			t.Errorf("%s = %v kg at %v°C, want %v kg at %v°C", game.Identifier(want[i]), gs.Quantity, gs.CurrentHeat, ws.Quantity, ws.CurrentHeat)
+		}
+	}
+	furnace := func(l *game.Level) *game.Furnace {
+		for _, m := range l.System.Machines {
+			if f, ok := m.(*game.Furnace); ok {
+				return f
+			}
+		}
+		t.Fatal("no furnace")
+		return nil
+	}
+	if math.Abs(furnace(r).Fuel()-furnace(l).Fuel()) > 0 || furnace(r).Exhausted != furnace(l).Exhausted {
+		t.Errorf("furnace fuel %v, want %v", furnace(r).Fuel(), furnace(l).Fuel())
+	}
+}
+
+func TestGame_SaveLoad(t *testing.T) {
+	g, err := game.NewGame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.System.StepN(12)
+	var first bytes.Buffer
+	if err := g.Save(&first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	other, _ := game.NewGame()
+	if err := other.Load(bytes.NewReader(first.Bytes())); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if other.System.Clock.Steps != 12 {
+		t.Errorf("loaded at step %d, want 12", other.System.Clock.Steps)
+	}
+	var second bytes.Buffer
+	if err := other.Save(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Error("saving a loaded game gave a different save")
+	}
+}
+
+func TestReadSave_Migrations(t *testing.T) {
+	l := setupTestLevel(t)
+	f, err := l.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(f)
+
+	// An older save that called CurrentHeat Temperature.
+	old := strings.ReplaceAll(string(data), `"CurrentHeat"`, `"Temperature"`)
+	old = strings.Replace(old, `"Version":1`, `"Version":0`, 1)
+
+	if _, err := game.ReadSave(strings.NewReader(old)); err == nil {
+		t.Error("ReadSave() read a version 0 save without a migration")
+	}
+
+	game.SaveMigrations[0] = func(save map[string]any) error {
+		return game.ForEachState(save, func(state map[string]any) error {
+			state["CurrentHeat"] = state["Temperature"]
+			delete(state, "Temperature")
+			return nil
+		})
+	}
+	defer delete(game.SaveMigrations, 0)
+
+	f, err = game.ReadSave(strings.NewReader(old))
+	if err != nil {
+		t.Fatalf("ReadSave() error = %v", err)
+	}
+	if f.Version != game.SaveVersion {
+		t.Errorf("Version = %d, want %d", f.Version, game.SaveVersion)
+	}
+	r, err := game.Restore(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.System.Nodes[0].GetStructurals().CurrentHeat; got != game.AmbientTemp {
+		t.Errorf("migrated CurrentHeat = %v, want %v", got, game.AmbientTemp)
+	}
+
+	newer := strings.Replace(string(data), `"Version":1`, `"Version":99`, 1)
+	if _, err := game.ReadSave(strings.NewReader(newer)); err == nil {
+		t.Error("ReadSave() read a save from a newer version")
+	}
+}
@@ -86,14 +86,14 @@ func TestTotalHead(t *testing.T) {
 			c: &game.Reservoir{
 				Structurals: game.Structurals{
 					MaxVolume:     10.0,
-					Quantity:      5.0,
+					Quantity:      50.0,
 					Contents:      []game.MaterialDef{{Type: game.TypeGas, GasConstant: 100}},
 					BaseElevation: 0,
 				},
 			},
-			// Pressure = (5 * 100) / 10 = 50
-			// Head = P / (rho_water * g) = 50 / (1000 * 9.81)
-			want: 50.0 / (1000.0 * 9.81),
+			// Pressure = (50 * 100 * 273.15) / 10 = 136575 at 0°C
+			// Head = (P - P_atm) / (rho_water * g)
+			want: (136575.0 - 101325.0) / (1000.0 * 9.81),
 		},
 	}
 	for _, tt := range tests {
@@ -197,3 +197,17 @@ func Test_calculateFlow(t *testing.T) {
 		t.Error("r2 should have positive PendingChange")
 	}
 }
+
+func Test_calculateFlow_Reverse(t *testing.T) {
+	r1 := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 100, Quantity: 0, BaseElevation: 0, Contents: []game.MaterialDef{game.Water}}}
+	r2 := &game.Reservoir{Structurals: game.Structurals{MaxVolume: 100, Quantity: 100, BaseElevation: 10, Contents: []game.MaterialDef{game.Water}}}
+
+	game.CalculateFlow(r1, r2, 0)
+
+	if r2.PendingChange >= 0 {
+		t.Error("r2 should have negative PendingChange when it has the higher head")
+	}
+	if r1.PendingChange <= 0 {
+		t.Error("r1 should have positive PendingChange when it has the lower head")
+	}
+}
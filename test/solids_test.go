@@ -0,0 +1,137 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func newHopper(id string, coal float64) *game.Hopper {
+	h := &game.Hopper{
+		Basics:      game.Basics{Identifier: id},
+		Structurals: game.Structurals{MaxVolume: 10, CurrentHeat: game.AmbientTemp},
+	}
+	h.AddMaterial(game.Coal, coal)
+	return h
+}
+
+func TestConveyor_CarriesCoal(t *testing.T) {
+	src, dst := newHopper("S", 1000), newHopper("D", 0)
+	belt := &game.Conveyor{From: src, To: dst, Length: 3, Speed: 1, Throughput: 6}
+	s := &game.System{Nodes: []game.Component{src, dst}, Machines: []game.Component{belt}}
+
+	// Nothing arrives before the first batch has covered the 3 tiles.
+	s.StepN(17) // 2.83 s at the default step
+	if got := dst.AmountOf(game.Coal); got != 0 {
+		t.Fatalf("coal arrived after %.2f s: %v", s.Clock.Time, got)
+	}
+	s.StepN(13)
+	if got := dst.AmountOf(game.Coal); got <= 0 {
+		t.Fatalf("no coal arrived after %.2f s", s.Clock.Time)
+	}
+
+	// The belt never picks up more than its throughput.
+	if loaded := 1000 - src.AmountOf(game.Coal); loaded > 6*s.Clock.Time+1e-9 {
+		t.Errorf("belt loaded %v kg in %.2f s, over 6 kg/s", loaded, s.Clock.Time)
+	}
+	total := src.Quantity + dst.Quantity + belt.Quantity
+	if math.Abs(total-1000) > 1e-9 {
+		t.Errorf("coal total = %v, want 1000", total)
+	}
+}
+
+func TestConveyor_Stalls(t *testing.T) {
+	src := newHopper("S", 1000)
+	dst := newHopper("D", 0)
+	dst.MaxVolume = 0.001 // Room for 1.5 kg of coal
+	belt := &game.Conveyor{From: src, To: dst, Length: 1, Speed: 1, Throughput: 6}
+	s := &game.System{Nodes: []game.Component{src, dst}, Machines: []game.Component{belt}}
+
+	s.StepN(30)
+	if !belt.Stalled {
+		t.Error("belt did not stall behind a full hopper")
+	}
+	if got := dst.AmountOf(game.Coal); math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("full hopper holds %v, want 1.5", got)
+	}
+	if total := src.Quantity + dst.Quantity + belt.Quantity; math.Abs(total-1000) > 1e-9 {
+		t.Errorf("coal total = %v, want 1000", total)
+	}
+}
+
+func TestFeeder_Meters(t *testing.T) {
+	src := newHopper("S", 100)
+	dst := newHopper("D", 0)
+	f := &game.Feeder{Source: src, Target: dst, Rate: 0.5, On: true}
+	s := &game.System{Nodes: []game.Component{src, dst}, Machines: []game.Component{f}}
+
+	s.StepN(60)
+	if got, want := dst.AmountOf(game.Coal), 0.5*s.Clock.Time; math.Abs(got-want) > 1e-9 {
+		t.Errorf("fed %v kg, want %v", got, want)
+	}
+	if math.Abs(f.Fed-0.5) > 1e-9 {
+		t.Errorf("Fed = %v, want 0.5", f.Fed)
+	}
+
+	f.On = false
+	before := dst.Quantity
+	s.StepN(10)
+	if dst.Quantity != before || f.Fed != 0 {
+		t.Error("feeder fed while off")
+	}
+
+	f.SetRate(-1)
+	if f.Rate != 0 {
+		t.Errorf("SetRate(-1) = %v, want 0", f.Rate)
+	}
+}
+
+func TestSolve_PipesLeaveSolids(t *testing.T) {
+	a, b := newSink("A"), newSink("B")
+	a.BaseElevation = 10
+	a.AddMaterial(game.Water, 1000)
+	a.AddMaterial(game.Coal, 500)
+	s := &game.System{Nodes: []game.Component{a, b}, Pipes: []*game.Pipe{game.NewPipe(a, b, 2, 0.5)}}
+
+	s.StepN(200)
+	if got := a.AmountOf(game.Coal); got != 500 {
+		t.Errorf("coal left in A = %v, want 500", got)
+	}
+	if got := b.AmountOf(game.Water); got <= 0 {
+		t.Error("water did not flow past the coal")
+	}
+}
+
+func TestLevel_Spawn_Conveyor(t *testing.T) {
+	l := setupTestLevel(t)
+	before := len(l.Entities())
+
+	ent, err := l.Spawn(game.EntityConfig{
+		Type: "Conveyor",
+		X:    0, Y: 0,
+		Identifier: "C1",
+		Path:       [][2]int{{0, 1}, {0, 2}},
+	})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	belt, ok := ent.Component.(*game.Conveyor)
+	if !ok {
+		t.Fatalf("Spawn component = %T, want *game.Conveyor", ent.Component)
+	}
+	if belt.Length != 3 || belt.Speed != 1 || belt.Throughput != 10 {
+		t.Errorf("conveyor = length %v speed %v throughput %v", belt.Length, belt.Speed, belt.Throughput)
+	}
+	if got := len(l.Entities()) - before; got != 3 {
+		t.Errorf("conveyor laid on %d tiles, want 3", got)
+	}
+	if len(l.System.Machines) != 1 {
+		t.Errorf("conveyor registered as %d machines, want 1", len(l.System.Machines))
+	}
+
+	ent, _ = l.Spawn(game.EntityConfig{Type: "Hopper", X: 1, Y: 0, InitialQty: 50})
+	if h := ent.Component.(*game.Hopper); h.AmountOf(game.Coal) != 50 {
+		t.Errorf("hopper holds %v coal, want 50", h.AmountOf(game.Coal))
+	}
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func newSink(id string) *game.Reservoir {
+	return &game.Reservoir{
+		Basics: game.Basics{Identifier: id},
+		Structurals: game.Structurals{
+			Area:      10,
+			MaxVolume: 1000,
+			Contents:  []game.MaterialDef{game.Water},
+		},
+	}
+}
+
+func totalQuantity(s *game.System) float64 {
+	total := 0.0
+	for _, c := range s.Network() {
+		total += c.GetStructurals().Quantity
+	}
+	return total
+}
+
+func TestSystem_Network(t *testing.T) {
+	a, b := newSink("A"), newSink("B")
+	p := game.NewPipe(a, b, 10, 1)
+	s := &game.System{
+		Nodes: []game.Component{a, a}, // duplicate registration
+		Pipes: []*game.Pipe{p},
+	}
+
+	got := s.Network()
+	if len(got) != 3 {
+		t.Fatalf("Network() len = %d, want 3", len(got))
+	}
+	if got[0] != a || got[1] != b || got[2] != p {
+		t.Errorf("Network() order = %v, want [A B pipe]", got)
+	}
+}
+
+func TestSystem_Solve_OverSubscribedSource(t *testing.T) {
+	src := newSink("S")
+	src.BaseElevation = 100 // Huge head, tiny stock
+	src.Quantity = 1
+
+	p1 := game.NewPipe(src, newSink("B"), 10, 1)
+	p2 := game.NewPipe(src, newSink("C"), 10, 1)
+
+	s := &game.System{
+		Nodes: []game.Component{src, p1.To, p2.To},
+		Pipes: []*game.Pipe{p1, p2},
+	}
+	s.Solve(game.TimeStep)
+
+	if math.Abs(src.PendingChange+1) > 1e-9 {
+		t.Errorf("source PendingChange = %v, want -1", src.PendingChange)
+	}
+	if math.Abs(p1.PendingChange-0.5) > 1e-9 || math.Abs(p2.PendingChange-0.5) > 1e-9 {
+		t.Errorf("pipe PendingChange = %v, %v, want 0.5 each", p1.PendingChange, p2.PendingChange)
+	}
+}
+
+func TestSystem_Solve_OrderIndependent(t *testing.T) {
+	build := func(reverse bool) (*game.System, *game.Reservoir, *game.Reservoir) {
+		src := newSink("S")
+		src.BaseElevation = 5
+		src.Quantity = 3000
+		b, c := newSink("B"), newSink("C")
+		p1 := game.NewPipe(src, b, 10, 1)
+		p2 := game.NewPipe(src, c, 5, 0.5)
+
+		s := &game.System{
+			Nodes: []game.Component{src, b, c},
+			Pipes: []*game.Pipe{p1, p2},
+		}
+		if reverse {
+			s.Nodes = []game.Component{c, b, src}
+			s.Pipes = []*game.Pipe{p2, p1}
+		}
+		return s, b, c
+	}
+
+	s1, b1, c1 := build(false)
+	s2, b2, c2 := build(true)
+	for i := 0; i < 200; i++ {
+		s1.Tick()
+		s2.Tick()
+	}
+
+	if math.Abs(b1.Quantity-b2.Quantity) > 1e-6 || math.Abs(c1.Quantity-c2.Quantity) > 1e-6 {
+		t.Errorf("results depend on order: B %v vs %v, C %v vs %v", b1.Quantity, b2.Quantity, c1.Quantity, c2.Quantity)
+	}
+	if b1.Quantity <= 0 || c1.Quantity <= 0 {
+		t.Errorf("no flow reached sinks: B=%v C=%v", b1.Quantity, c1.Quantity)
+	}
+}
+
+func TestSystem_Tick_ConservesMass(t *testing.T) {
+	src := newSink("S")
+	src.BaseElevation = 50
+	src.Quantity = 10 // Drained completely within a few steps
+
+	sinks := []*game.Reservoir{newSink("B"), newSink("C"), newSink("D")}
+	s := &game.System{Nodes: []game.Component{src}}
+	for _, k := range sinks {
+		// Sinks are deliberately left out of Nodes.
+		s.Pipes = append(s.Pipes, game.NewPipe(src, k, 2, 0.5))
+	}
+
+	before := totalQuantity(s)
+	for i := 0; i < 300; i++ {
+		s.Tick()
+	}
+	after := totalQuantity(s)
+
+	if math.Abs(before-after) > 1e-9 {
+		t.Errorf("mass not conserved: before=%v after=%v", before, after)
+	}
+	if src.Quantity > 1e-9 {
+		t.Errorf("source not drained: %v", src.Quantity)
+	}
+}
+
+func TestSystem_Solve_Bidirectional(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkValve bool
+		wantFlow   bool
+	}{
+		{name: "Open pipe flows back", checkValve: false, wantFlow: true},
+		{name: "Check valve blocks back flow", checkValve: true, wantFlow: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newSink("A"), newSink("B")
+			b.BaseElevation = 10 // B sits above A
+			b.Quantity = 5000
+
+			p := game.NewPipe(a, b, 10, 1)
+			p.CheckValve = tt.checkValve
+			s := &game.System{
+				Nodes: []game.Component{a, b},
+				Pipes: []*game.Pipe{p},
+			}
+			s.StepN(10)
+
+			if got := a.Quantity > 0; got != tt.wantFlow {
+				t.Errorf("A received flow = %v (qty=%v), want %v", got, a.Quantity, tt.wantFlow)
+			}
+			if math.Abs(b.Quantity+p.Quantity+a.Quantity-5000) > 1e-9 {
+				t.Errorf("mass not conserved: %v", b.Quantity+p.Quantity+a.Quantity)
+			}
+		})
+	}
+}
+
+func TestSystem_Network_NilPipe(t *testing.T) {
+	a, b := newSink("A"), newSink("B")
+	s := &game.System{Pipes: []*game.Pipe{nil, game.NewPipe(a, b, 10, 1)}}
+	if got := len(s.Network()); got != 3 {
+		t.Errorf("Network() len = %d, want 3", got)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+func TestHeatCapacity(t *testing.T) {
+	if got := game.HeatCapacity(nil); got != 0 {
+		t.Errorf("HeatCapacity(nil) = %v, want 0", got)
+	}
+	s := &game.Structurals{}
+	s.AddMaterial(game.Water, 2)
+	s.AddMaterial(game.Steam, 1)
+	want := 2*game.Water.SpecificHeat + game.Steam.SpecificHeat
+	if got := game.HeatCapacity(s); got != want {
+		t.Errorf("HeatCapacity() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyPending_Heat(t *testing.T) {
+	r := &game.Reservoir{Structurals: game.Structurals{CurrentHeat: 20, MaxHeat: 50}}
+	r.AddMaterial(game.Water, 1)
+
+	r.QueueHeat(game.Water.SpecificHeat * 10) // +10 K
+	game.ApplyPending(r)
+	if math.Abs(r.CurrentHeat-30) > 1e-9 {
+		t.Errorf("CurrentHeat = %v, want 30", r.CurrentHeat)
+	}
+	if r.Overheated {
+		t.Error("Overheated set below MaxHeat")
+	}
+
+	r.QueueHeat(game.Water.SpecificHeat * 30)
+	game.ApplyPending(r)
+	if !r.Overheated {
+		t.Errorf("Overheated not set at %v°C", r.CurrentHeat)
+	}
+}
+
+func TestSystem_Solve_CarriesHeat(t *testing.T) {
+	hot, cold := newSink("H"), newSink("C")
+	hot.BaseElevation = 10
+	hot.CurrentHeat = 80
+	hot.AddMaterial(game.Water, 3000)
+	cold.CurrentHeat = 20
+	cold.AddMaterial(game.Water, 1000)
+
+	p := game.NewPipe(hot, cold, 2, 0.5)
+	p.CurrentHeat = 80
+	s := &game.System{Nodes: []game.Component{hot, cold}, Pipes: []*game.Pipe{p}}
+
+	energy := func() float64 {
+		e := 0.0
+		for _, c := range s.Network() {
+			st := c.GetStructurals()
+			e += game.HeatCapacity(st) * st.CurrentHeat
+		}
+		return e
+	}
+	before := energy()
+
+	for range 20 {
+		s.Solve(game.TimeStep)
+		for _, c := range s.Network() {
+			game.ApplyPending(c)
+		}
+	}
+
+	if cold.CurrentHeat <= 20 || cold.CurrentHeat >= 80 {
+		t.Errorf("mixed temperature = %v, want between 20 and 80", cold.CurrentHeat)
+	}
+	if math.Abs(hot.CurrentHeat-80) > 1e-9 {
+		t.Errorf("source temperature changed to %v by outflow", hot.CurrentHeat)
+	}
+	if after := energy(); math.Abs(after-before)/before > 1e-9 {
+		t.Errorf("energy not conserved: before=%v after=%v", before, after)
+	}
+}
+
+func TestSystem_Step_ConductionAndAmbient(t *testing.T) {
+	a, b := newSink("A"), newSink("B")
+	a.CurrentHeat, b.CurrentHeat = 90, 20
+	a.AddMaterial(game.Water, 10)
+	b.AddMaterial(game.Water, 10)
+
+	p := game.NewPipe(a, b, 1, 0.5)
+	p.CurrentHeat = 20
+	p.AddMaterial(game.Water, 1)
+	s := &game.System{Nodes: []game.Component{a, b}, Pipes: []*game.Pipe{p}}
+
+	s.StepN(2000)
+
+	for _, c := range []*game.Structurals{&a.Structurals, &b.Structurals, &p.Structurals} {
+		if c.CurrentHeat < game.AmbientTemp-1e-6 {
+			t.Errorf("temperature %v fell below ambient", c.CurrentHeat)
+		}
+	}
+	if a.CurrentHeat >= 90 {
+		t.Errorf("hot tank did not cool: %v", a.CurrentHeat)
+	}
+	if b.CurrentHeat <= 20 {
+		t.Errorf("cold tank did not warm through the pipe: %v", b.CurrentHeat)
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/padilin/gengeno/game"
+)
+
+// kinds counts the issues of each kind in err.
+func kinds(t *testing.T, err error) map[game.IssueKind]int {
+	t.Helper()
+	got := make(map[game.IssueKind]int)
+	if err == nil {
+		return got
+	}
+	var issues game.ValidationErrors
+	if !errors.As(err, &issues) {
+		t.Fatalf("Validate() = %T, want game.ValidationErrors", err)
+	}
+	for _, e := range issues {
+		got[e.Kind]++
+	}
+	return got
+}
+
+func TestValidate_Clean(t *testing.T) {
+	a, b := newSink("A"), newSink("B")
+	s := &game.System{
+		Nodes: []game.Component{a, b},
+		Pipes: []*game.Pipe{game.NewPipe(a, b, 10, 0.5)},
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidate_Issues(t *testing.T) {
+	a, b, c := newSink("A"), newSink("B"), newSink("C")
+	joined := game.NewPipe(a, b, 10, 0.5)
+	dangling := game.NewPipe(nil, b, 10, 0.5)
+	unregistered := game.NewPipe(a, newSink("X"), 10, 0.5)
+	s := &game.System{
+		Nodes: []game.Component{a, b, a, c},
+		Pipes: []*game.Pipe{joined, dangling, unregistered, joined},
+	}
+
+	got := kinds(t, s.Validate())
+	want := map[game.IssueKind]int{
+		game.DuplicateNode:   1,
+		game.DuplicatePipe:   1,
+		game.DanglingPipe:    1,
+		game.UnregisteredEnd: 1,
+		game.IsolatedNetwork: 1, // C
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("%v issues = %d, want %d", k, got[k], n)
+		}
+	}
+
+	var single *game.ValidationError
+	if !errors.As(s.Validate(), &single) {
+		t.Error("errors.As could not reach a single ValidationError")
+	}
+}
+
+func TestValidate_Isolated(t *testing.T) {
+	a, b, c, d, e := newSink("A"), newSink("B"), newSink("C"), newSink("D"), newSink("E")
+	s := &game.System{
+		Nodes: []game.Component{a, b, c, d, e},
+		Pipes: []*game.Pipe{
+			game.NewPipe(a, b, 10, 0.5),
+			game.NewPipe(b, c, 10, 0.5),
+			game.NewPipe(d, e, 10, 0.5),
+		},
+	}
+
+	var issues game.ValidationErrors
+	if !errors.As(s.Validate(), &issues) || len(issues) != 1 {
+		t.Fatalf("Validate() = %v, want one isolated network", issues)
+	}
+	iso := issues[0]
+	if iso.Kind != game.IsolatedNetwork || len(iso.Components) != 3 {
+		t.Errorf("issue = %v with %d components, want isolated network of D, E and its pipe", iso, len(iso.Components))
+	}
+
+	// A feeder ties an otherwise separate hopper into the plant.
+	h := &game.Hopper{Structurals: game.Structurals{MaxVolume: 10}}
+	s = &game.System{
+		Nodes:    []game.Component{a, b, h},
+		Pipes:    []*game.Pipe{game.NewPipe(a, b, 10, 0.5)},
+		Machines: []game.Component{&game.Feeder{Source: h, Target: a}},
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Validate() with feeder = %v, want nil", err)
+	}
+}
+
+func TestStep_DanglingPipe(t *testing.T) {
+	a := newSink("A")
+	a.AddMaterial(game.Water, 1000)
+	s := &game.System{
+		Nodes: []game.Component{a},
+		Pipes: []*game.Pipe{game.NewPipe(a, nil, 10, 0.5), nil},
+	}
+	s.Step() // Must not panic
+}
+
+func TestLevel_Issues(t *testing.T) {
+	l := setupTestLevel(t)
+	if err := l.Issues(); err != nil {
+		t.Fatalf("Issues() on the default level = %v, want nil", err)
+	}
+
+	ent, err := l.Spawn(game.EntityConfig{Type: "Reservoir", X: 3, Y: 3, Identifier: "LONE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(t, l.Issues()); got[game.IsolatedNetwork] != 1 {
+		t.Errorf("Issues() after Spawn found %v, want an isolated network", got)
+	}
+
+	// Changes made behind the level's back wait for Revalidate.
+	l.System.Pipes = append(l.System.Pipes, game.NewPipe(ent.Component, nil, 10, 0.5))
+	if got := kinds(t, l.Issues()); got[game.DanglingPipe] != 0 {
+		t.Errorf("Issues() changed without Revalidate: %v", got)
+	}
+	l.Revalidate()
+	if got := kinds(t, l.Issues()); got[game.DanglingPipe] != 1 {
+		t.Errorf("Issues() after Revalidate found %v, want a dangling pipe", got)
+	}
+
+	if _, err := l.Despawn(ent.Component, game.VanishContents); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Issues(); err != nil {
+		t.Errorf("Issues() after Despawn = %v, want nil", err)
+	}
+}